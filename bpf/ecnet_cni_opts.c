@@ -22,7 +22,12 @@ __section("cgroup/getsockopt") int get_sockopt(struct bpf_sockopt *ctx)
         return 1;
     }
 
-    __u16 bridge_port = bpf_htons(ECNET_PROXY_PORT);
+    struct ecnet_cfg *cfg = get_config();
+    if (!cfg) {
+        return 1;
+    }
+
+    __u16 bridge_port = bpf_htons(cfg->proxy_port);
     struct pair p;
     memset(&p, 0, sizeof(p));
     struct origin_info *origin;
@@ -33,20 +38,16 @@ __section("cgroup/getsockopt") int get_sockopt(struct bpf_sockopt *ctx)
         p.sport = bridge_port;
         p.dport = ctx->sk->dst_port;
 
-#ifdef DEBUG
         debugf("ecnet_cni_skopts [sockopt]: LOOKUP Pair sip: %pI4 sport: %d",
                &p.sip, bpf_ntohs(p.sport));
         debugf("ecnet_cni_skopts [sockopt]: LOOKUP Pair dip: %pI4 dport: %d",
                &p.dip, bpf_ntohs(p.dport));
-#endif
 
         origin = bpf_map_lookup_elem(&ecnet_svc_nat, &p);
         if (origin) {
-#ifdef DEBUG
             debugf(
                 "ecnet_cni_skopts [sockopt]: LOOKUP Origin ip: %pI4 port: %d",
                 &origin->ip, bpf_ntohs(origin->port));
-#endif
             // rewrite original_dst
             ctx->optlen = (__s32)sizeof(struct sockaddr_in);
             if ((void *)((struct sockaddr_in *)ctx->optval + 1) >
@@ -63,6 +64,7 @@ __section("cgroup/getsockopt") int get_sockopt(struct bpf_sockopt *ctx)
                 .sin_port = origin->port,
             };
             *(struct sockaddr_in *)ctx->optval = sa;
+            stat_inc(ECNET_STAT_ORIGINAL_DST_REWRITE);
         }
         break;
     }
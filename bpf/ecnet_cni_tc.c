@@ -30,7 +30,15 @@
 #define UDP_DPORT_OFF                                                          \
     (ETH_HLEN + sizeof(struct iphdr) + offsetof(struct udphdr, dest))
 
+static inline int is_clusterset_vip(struct ecnet_cfg *cfg, __u32 addr)
+{
+    return cfg->clusterset_vip_mask &&
+           (bpf_ntohl(addr) & cfg->clusterset_vip_mask) ==
+               cfg->clusterset_vip_net;
+}
+
 static inline int process_tcp_ingress_packet(struct __sk_buff *skb,
+                                             struct ecnet_cfg *cfg,
                                              struct iphdr *iph, void *data_end)
 {
     struct tcphdr *tcph = (struct tcphdr *)(iph + 1);
@@ -38,24 +46,22 @@ static inline int process_tcp_ingress_packet(struct __sk_buff *skb,
         return TC_ACT_SHOT;
     }
 
-    __u32 bridge_ip = bpf_htonl(BRIDGE_IP);
+    __u32 bridge_ip = bpf_htonl(cfg->bridge_ip);
     if (iph->saddr != bridge_ip) {
         return TC_ACT_OK;
     }
 
-    __u16 bridge_port = bpf_htons(ECNET_PROXY_PORT);
+    __u16 bridge_port = bpf_htons(cfg->proxy_port);
     if (tcph->source != bridge_port) {
         return TC_ACT_OK;
     }
 
-#ifdef DEBUG
     debugf("---------------------------------------------------------");
     debugf("ecnet_cni_tcp_tc [ingress]: SRC ip: %pI4 port: %d", &iph->saddr,
            bpf_ntohs(tcph->source));
     debugf("ecnet_cni_tcp_tc [ingress]: DST ip: %pI4 port: %d", &iph->daddr,
            bpf_ntohs(tcph->dest));
     debugf("ecnet_cni_tcp_tc [ingress]: FIN: %d ACK: %d", tcph->fin, tcph->ack);
-#endif
 
     struct pair p;
     memset(&p, 0, sizeof(p));
@@ -64,21 +70,24 @@ static inline int process_tcp_ingress_packet(struct __sk_buff *skb,
     p.dport = tcph->dest;
     p.sport = tcph->source;
 
-#ifdef DEBUG
     debugf("ecnet_cni_tcp_tc [ingress]: LOOKUP Pair sip: %pI4 sport: %d",
            &p.sip, bpf_htons(p.sport));
     debugf("ecnet_cni_tcp_tc [ingress]: LOOKUP Pair dip: %pI4 dport: %d",
            &p.dip, bpf_htons(p.dport));
-#endif
 
     struct origin_info *origin = bpf_map_lookup_elem(&ecnet_svc_nat, &p);
     if (!origin) {
         // debugf("ecnet_cni_tcp_tc [ingress]: original not found");
+        stat_inc(ECNET_STAT_SVC_NAT_MISS);
         return TC_ACT_OK;
     }
-    if (tcph->fin && tcph->ack) {
+    stat_inc(ECNET_STAT_SVC_NAT_HIT);
+    origin->last_seen = bpf_ktime_get_ns();
+    if ((tcph->fin && tcph->ack) || tcph->rst) {
         // debugf("ecnet_cni_tcp_tc [ingress]: original deleted");
-        bpf_map_delete_elem(&ecnet_svc_nat, &p);
+        if (!bpf_map_delete_elem(&ecnet_svc_nat, &p)) {
+            stat_inc(ECNET_STAT_SVC_NAT_DELETE);
+        }
     }
 
     __u32 tcp_csum_off = TCP_CSUM_OFF;
@@ -87,28 +96,97 @@ static inline int process_tcp_ingress_packet(struct __sk_buff *skb,
     //__u32 saddr_off = IP_SRC_OFF;
 
     __u16 sport = tcph->source;
-    //__u32 saddr = iph->saddr;
+    __u32 saddr = iph->saddr;
     __u16 origin_sport = origin->port;
-    //__u32 origin_saddr = origin->ip;
+    __u32 origin_saddr = origin->ip;
 
     bpf_l4_csum_replace(skb, tcp_csum_off, sport, origin_sport, sizeof(sport));
-    // bpf_l4_csum_replace(skb, tcp_csum_off, saddr, origin_saddr, IS_PSEUDO |
-    // sizeof(saddr)); bpf_l3_csum_replace(skb, ip_csum_off, saddr,
-    // origin_saddr, sizeof(saddr));
     bpf_skb_store_bytes(skb, sport_off, &origin_sport, sizeof(origin_sport), 0);
-    // bpf_skb_store_bytes(skb, saddr_off, &origin_saddr, sizeof(origin_saddr),
-    // 0);
 
-#ifdef DEBUG
-    // debugf("ecnet_cni_tcp_tc [ingress]: snat %pI4 -> %pI4", &saddr,
-    // &origin->ip);
+    // replies of the ClusterSetIP imports come from their virtual IP
+    if (origin_saddr != saddr) {
+        __u32 ip_csum_off = IP_CSUM_OFF;
+        __u32 saddr_off = IP_SRC_OFF;
+        bpf_l4_csum_replace(skb, tcp_csum_off, saddr, origin_saddr,
+                            IS_PSEUDO | sizeof(saddr));
+        bpf_l3_csum_replace(skb, ip_csum_off, saddr, origin_saddr,
+                            sizeof(saddr));
+        bpf_skb_store_bytes(skb, saddr_off, &origin_saddr, sizeof(origin_saddr),
+                            0);
+    }
+
+    debugf("ecnet_cni_tcp_tc [ingress]: SNAT %pI4 -> %pI4", &saddr,
+           &origin_saddr);
     debugf("ecnet_cni_tcp_tc [ingress]: SNAT %d -> %d", bpf_ntohs(sport),
            bpf_ntohs(origin_sport));
-#endif
+    stat_inc(ECNET_STAT_INGRESS_REWRITE);
+    return TC_ACT_OK;
+}
+
+static inline int process_udp_svc_ingress_packet(struct __sk_buff *skb,
+                                                 struct ecnet_cfg *cfg,
+                                                 struct iphdr *iph,
+                                                 struct udphdr *udph)
+{
+    struct pair p;
+    memset(&p, 0, sizeof(p));
+    p.dip = iph->daddr;
+    p.sip = iph->saddr;
+    p.dport = udph->dest;
+    p.sport = udph->source;
+
+    struct udp_origin_info *origin = bpf_map_lookup_elem(&ecnet_udp_nat, &p);
+    if (!origin) {
+        stat_inc(ECNET_STAT_UDP_NAT_MISS);
+        return TC_ACT_OK;
+    }
+    stat_inc(ECNET_STAT_UDP_NAT_HIT);
+    origin->last_seen = bpf_ktime_get_ns();
+
+    __u32 udp_csum_off = UDP_CSUM_OFF;
+    __u32 udp_sport_off = UDP_SPORT_OFF;
+    __u32 udp_dport_off = UDP_DPORT_OFF;
+    __u16 sport = udph->source;
+    __u16 dport = udph->dest;
+    __u32 saddr = iph->saddr;
+    __u16 origin_sport = origin->port;
+    __u16 origin_dport = origin->sport;
+    __u32 origin_saddr = origin->ip;
+
+    bpf_l4_csum_replace(skb, udp_csum_off, sport, origin_sport, sizeof(sport));
+    bpf_skb_store_bytes(skb, udp_sport_off, &origin_sport, sizeof(origin_sport),
+                        0);
+
+    // the flow was seen by the bridge proxy from another port than the socket's
+    if (origin_dport != dport) {
+        bpf_l4_csum_replace(skb, udp_csum_off, dport, origin_dport,
+                            sizeof(dport));
+        bpf_skb_store_bytes(skb, udp_dport_off, &origin_dport,
+                            sizeof(origin_dport), 0);
+    }
+
+    // replies of the ClusterSetIP imports come from their virtual IP
+    if (origin_saddr != saddr) {
+        __u32 ip_csum_off = IP_CSUM_OFF;
+        __u32 saddr_off = IP_SRC_OFF;
+        bpf_l4_csum_replace(skb, udp_csum_off, saddr, origin_saddr,
+                            IS_PSEUDO | sizeof(saddr));
+        bpf_l3_csum_replace(skb, ip_csum_off, saddr, origin_saddr,
+                            sizeof(saddr));
+        bpf_skb_store_bytes(skb, saddr_off, &origin_saddr, sizeof(origin_saddr),
+                            0);
+    }
+
+    debugf("ecnet_cni_udp_tc [ingress]: SNAT %pI4 -> %pI4", &saddr,
+           &origin_saddr);
+    debugf("ecnet_cni_udp_tc [ingress]: SNAT %d -> %d", bpf_ntohs(sport),
+           bpf_ntohs(origin_sport));
+    stat_inc(ECNET_STAT_INGRESS_REWRITE);
     return TC_ACT_OK;
 }
 
 static inline int process_udp_ingress_packet(struct __sk_buff *skb,
+                                             struct ecnet_cfg *cfg,
                                              struct iphdr *iph, void *data_end)
 {
     struct udphdr *udph = (struct udphdr *)(iph + 1);
@@ -116,20 +194,24 @@ static inline int process_udp_ingress_packet(struct __sk_buff *skb,
         return TC_ACT_SHOT;
     }
 
-    __u16 dns_port = bpf_htons(DNS_PROXY_PORT);
+    // replies of the udp services imported through the bridge
+    if (iph->saddr == bpf_htonl(cfg->bridge_ip) &&
+        udph->source == bpf_htons(cfg->udp_proxy_port)) {
+        return process_udp_svc_ingress_packet(skb, cfg, iph, udph);
+    }
+
+    __u16 dns_port = bpf_htons(cfg->dns_proxy_port);
     if (udph->source != dns_port) {
         return TC_ACT_OK;
     }
 
-#ifdef DEBUG
     debugf("---------------------------------------------------------");
     debugf("mcs_cni_udp_tc [ingress]: SRC ip: %pI4 port: %d", &iph->saddr,
            bpf_ntohs(udph->source));
     debugf("mcs_cni_udp_tc [ingress]: DST ip: %pI4 port: %d", &iph->daddr,
            bpf_ntohs(udph->dest));
-#endif
 
-    __u32 bridge_ip = bpf_htonl(BRIDGE_IP);
+    __u32 bridge_ip = bpf_htonl(cfg->bridge_ip);
     if (iph->saddr != bridge_ip) {
         return TC_ACT_OK;
     }
@@ -141,7 +223,6 @@ static inline int process_udp_ingress_packet(struct __sk_buff *skb,
     p.dport = udph->dest;
     p.sport = udph->source;
 
-#ifdef DEBUG
     debugf("---------------------------------------------------------");
     debugf("mcs_cni_udp_tc [ingress]: SRC ip: %pI4 port: %d", &iph->saddr,
            bpf_ntohs(udph->source));
@@ -151,17 +232,17 @@ static inline int process_udp_ingress_packet(struct __sk_buff *skb,
            bpf_ntohs(p.sport));
     debugf("mcs_cni_udp_tc [ingress]: LOOKUP Pair dip: %pI4 dport: %d", &p.dip,
            bpf_ntohs(p.dport));
-#endif
 
     struct origin_info *origin = bpf_map_lookup_elem(&ecnet_dns_nat, &p);
     if (!origin) {
         debugf("mcs_cni_udp_tc [ingress]: original not found");
+        stat_inc(ECNET_STAT_DNS_NAT_MISS);
         return TC_ACT_OK;
     }
-#ifdef DEBUG
+    stat_inc(ECNET_STAT_DNS_NAT_HIT);
+    origin->last_seen = bpf_ktime_get_ns();
     debugf("mcs_cni_udp_tc [ingress]: LOOKUP Origin ip: %pI4 port: %d",
            &origin->ip, bpf_ntohs(origin->port));
-#endif
 
     __u32 udp_csum_off = UDP_CSUM_OFF;
     __u32 udp_sport_off = UDP_SPORT_OFF;
@@ -179,13 +260,13 @@ static inline int process_udp_ingress_packet(struct __sk_buff *skb,
     bpf_skb_store_bytes(skb, udp_sport_off, &origin_sport, sizeof(origin_sport),
                         0);
 
-#ifdef DEBUG
     debugf("mcs_cni_udp_tc [ingress]: SNAT %pI4 -> %pI4", &saddr, &origin->ip);
-#endif
+    stat_inc(ECNET_STAT_INGRESS_REWRITE);
     return TC_ACT_OK;
 }
 
-__section("classifier_ingress") int ecnet_cni_tc_ingress(struct __sk_buff *skb)
+static inline int process_ingress_packet(struct __sk_buff *skb,
+                                         struct ecnet_cfg *cfg)
 {
     void *data = (void *)(long)skb->data;
     void *data_end = (void *)(long)skb->data_end;
@@ -209,9 +290,9 @@ __section("classifier_ingress") int ecnet_cni_tc_ingress(struct __sk_buff *skb)
             }
         }
         if (iph->protocol == IPPROTO_TCP) {
-            return process_tcp_ingress_packet(skb, iph, data_end);
+            return process_tcp_ingress_packet(skb, cfg, iph, data_end);
         } else if (iph->protocol == IPPROTO_UDP) {
-            return process_udp_ingress_packet(skb, iph, data_end);
+            return process_udp_ingress_packet(skb, cfg, iph, data_end);
         }
         return TC_ACT_OK;
     }
@@ -220,7 +301,22 @@ __section("classifier_ingress") int ecnet_cni_tc_ingress(struct __sk_buff *skb)
     }
 }
 
+__section("classifier_ingress") int ecnet_cni_tc_ingress(struct __sk_buff *skb)
+{
+    struct ecnet_cfg *cfg = get_config();
+    if (!cfg) {
+        return TC_ACT_OK;
+    }
+
+    int ret = process_ingress_packet(skb, cfg);
+    if (ret == TC_ACT_SHOT) {
+        stat_inc(ECNET_STAT_INGRESS_DROP);
+    }
+    return ret;
+}
+
 static inline int process_tcp_egress_packet(struct __sk_buff *skb,
+                                            struct ecnet_cfg *cfg,
                                             struct iphdr *iph, void *data_end)
 {
     struct tcphdr *tcph = (struct tcphdr *)(iph + 1);
@@ -228,24 +324,25 @@ static inline int process_tcp_egress_packet(struct __sk_buff *skb,
         return TC_ACT_SHOT;
     }
 
-    __u32 bridge_ip = bpf_htonl(BRIDGE_IP);
-    if (iph->daddr != bridge_ip) {
+    __u32 bridge_ip = bpf_htonl(cfg->bridge_ip);
+    __u32 daddr = iph->daddr;
+    // the ClusterSetIP imports are resolved to their virtual IP
+    int to_vip = is_clusterset_vip(cfg, daddr);
+    if (daddr != bridge_ip && !to_vip) {
         return TC_ACT_OK;
     }
 
-    __u16 bridge_port = bpf_htons(ECNET_PROXY_PORT);
+    __u16 bridge_port = bpf_htons(cfg->proxy_port);
     if (tcph->dest == bridge_port) {
         return TC_ACT_OK;
     }
 
-#ifdef DEBUG
     debugf("---------------------------------------------------------");
     debugf("ecnet_cni_tcp_tc [egress]: SRC ip: %pI4 port: %d", &iph->saddr,
            bpf_ntohs(tcph->source));
     debugf("ecnet_cni_tcp_tc [egress]: DST ip: %pI4 port: %d", &iph->daddr,
            bpf_ntohs(tcph->dest));
     debugf("ecnet_cni_tcp_tc [egress]: FIN: %d ACK: %d", tcph->fin, tcph->ack);
-#endif
 
     if (tcph->syn && !tcph->ack) {
         struct pair p;
@@ -259,53 +356,208 @@ static inline int process_tcp_egress_packet(struct __sk_buff *skb,
         memset(&origin, 0, sizeof(origin));
         origin.ip = iph->daddr;
         origin.port = tcph->dest;
+        origin.proto = IPPROTO_TCP;
+        origin.last_seen = bpf_ktime_get_ns();
 
-#ifdef DEBUG
         debugf("ecnet_cni_tcp_tc [egress]: STORE Pair sip: %pI4 sport: %d",
                &p.sip, bpf_ntohs(p.sport));
         debugf("ecnet_cni_tcp_tc [egress]: STORE Pair dip: %pI4 dport: %d",
                &p.dip, bpf_ntohs(p.dport));
-#endif
 
-        bpf_map_update_elem(&ecnet_svc_nat, &p, &origin, BPF_NOEXIST);
+        if (!bpf_map_update_elem(&ecnet_svc_nat, &p, &origin, BPF_NOEXIST)) {
+            stat_inc(ECNET_STAT_SVC_NAT_INSERT);
+        }
     } else {
-         struct pair p;
-         memset(&p, 0, sizeof(p));
-         p.dip = iph->saddr;
-         p.sip = bridge_ip;
-         p.dport = tcph->source;
-         p.sport = bridge_port;
-         struct origin_info *origin = bpf_map_lookup_elem(&ecnet_svc_nat, &p);
-         if (!origin) {
-             return TC_ACT_OK;
-         }
-     }
+        struct pair p;
+        memset(&p, 0, sizeof(p));
+        p.dip = iph->saddr;
+        p.sip = bridge_ip;
+        p.dport = tcph->source;
+        p.sport = bridge_port;
+        struct origin_info *origin = bpf_map_lookup_elem(&ecnet_svc_nat, &p);
+        if (!origin) {
+            stat_inc(ECNET_STAT_SVC_NAT_MISS);
+            return TC_ACT_OK;
+        }
+        stat_inc(ECNET_STAT_SVC_NAT_HIT);
+        origin->last_seen = bpf_ktime_get_ns();
+        if (tcph->rst) {
+            // the reset is still passed to the bridge proxy
+            if (!bpf_map_delete_elem(&ecnet_svc_nat, &p)) {
+                stat_inc(ECNET_STAT_SVC_NAT_DELETE);
+            }
+        }
+    }
 
     __u32 tcp_csum_off = TCP_CSUM_OFF;
-    //__u32 ip_csum_off = IP_CSUM_OFF;
     __u32 dport_off = TCP_DPORT_OFF;
-    //__u32 daddr_off = IP_DST_OFF;
 
     __u16 dport = tcph->dest;
-    //__u32 daddr = iph->daddr;
 
     bpf_l4_csum_replace(skb, tcp_csum_off, dport, bridge_port, sizeof(dport));
-    // bpf_l4_csum_replace(skb, tcp_csum_off, daddr, bridge_ip, IS_PSEUDO |
-    // sizeof(daddr)); bpf_l3_csum_replace(skb, ip_csum_off, daddr, bridge_ip,
-    // sizeof(daddr));
     bpf_skb_store_bytes(skb, dport_off, &bridge_port, sizeof(bridge_port), 0);
-    // bpf_skb_store_bytes(skb, daddr_off, &bridge_ip, sizeof(bridge_ip), 0);
 
-#ifdef DEBUG
-    // debugf("ecnet_cni_tcp_tc [egress]: dnat %pI4 -> %pI4", &daddr,
-    // &bridge_ip);
+    if (to_vip) {
+        __u32 ip_csum_off = IP_CSUM_OFF;
+        __u32 daddr_off = IP_DST_OFF;
+        bpf_l4_csum_replace(skb, tcp_csum_off, daddr, bridge_ip,
+                            IS_PSEUDO | sizeof(daddr));
+        bpf_l3_csum_replace(skb, ip_csum_off, daddr, bridge_ip, sizeof(daddr));
+        bpf_skb_store_bytes(skb, daddr_off, &bridge_ip, sizeof(bridge_ip), 0);
+    }
+
+    debugf("ecnet_cni_tcp_tc [egress]: DNAT %pI4 -> %pI4", &daddr, &bridge_ip);
     debugf("ecnet_cni_tcp_tc [egress]: DNAT %d -> %d", bpf_ntohs(dport),
            bpf_ntohs(bridge_port));
-#endif
+    stat_inc(ECNET_STAT_EGRESS_REWRITE);
+    return TC_ACT_OK;
+}
+
+#define UDP_NAT_PORT_MIN 32768
+#define UDP_NAT_PORT_RANGE 28232
+#define UDP_NAT_PORT_TRIES 8
+
+static inline int is_udp_origin(struct udp_origin_info *origin,
+                                struct pair *flow)
+{
+    return origin->ip == flow->dip && origin->port == flow->dport &&
+           origin->sport == flow->sport;
+}
+
+// reserve_udp_nat_port stores the nat entry of a new udp flow, keyed by p
+// whose dport is set to the port the flow is seen from by the bridge proxy.
+// The first flow of a socket keeps the port of the socket, the others are
+// given a random port of the ephemeral range. Returns 0 if no port is free.
+static inline __u16 reserve_udp_nat_port(struct pair *flow, struct pair *p)
+{
+    struct udp_origin_info origin;
+    memset(&origin, 0, sizeof(origin));
+    origin.ip = flow->dip;
+    origin.port = flow->dport;
+    origin.sport = flow->sport;
+    origin.last_seen = bpf_ktime_get_ns();
+
+    struct udp_flow_info flow_info;
+    memset(&flow_info, 0, sizeof(flow_info));
+
+    __u32 seed = bpf_get_prandom_u32();
+    __u16 nat_port = flow->sport;
+#pragma unroll
+    for (int i = 0; i < UDP_NAT_PORT_TRIES; i++) {
+        p->dport = nat_port;
+        struct udp_origin_info *existing =
+            bpf_map_lookup_elem(&ecnet_udp_nat, p);
+        if (existing && is_udp_origin(existing, flow)) {
+            existing->last_seen = origin.last_seen;
+        } else if (existing ||
+                   bpf_map_update_elem(&ecnet_udp_nat, p, &origin,
+                                       BPF_NOEXIST)) {
+            nat_port =
+                bpf_htons(UDP_NAT_PORT_MIN + (seed + i) % UDP_NAT_PORT_RANGE);
+            continue;
+        } else {
+            stat_inc(ECNET_STAT_UDP_NAT_INSERT);
+        }
+        flow_info.nat_port = nat_port;
+        bpf_map_update_elem(&ecnet_udp_flow, flow, &flow_info, BPF_ANY);
+        return nat_port;
+    }
+    return 0;
+}
+
+static inline int process_udp_svc_egress_packet(struct __sk_buff *skb,
+                                                struct ecnet_cfg *cfg,
+                                                struct iphdr *iph,
+                                                struct udphdr *udph)
+{
+    __u32 bridge_ip = bpf_htonl(cfg->bridge_ip);
+    __u16 bridge_port = bpf_htons(cfg->udp_proxy_port);
+    if (udph->dest == bridge_port) {
+        return TC_ACT_OK;
+    }
+
+    // There is no handshake to hook on, the flows are told apart by their
+    // original destination: each flow of a socket is seen by the bridge proxy
+    // from its own port. The entries are stored on the first datagram of the
+    // flow, refreshed by the next ones and expired by the cni controller once
+    // idle.
+    struct pair flow;
+    memset(&flow, 0, sizeof(flow));
+    flow.sip = iph->saddr;
+    flow.dip = iph->daddr;
+    flow.sport = udph->source;
+    flow.dport = udph->dest;
+
+    struct pair p;
+    memset(&p, 0, sizeof(p));
+    p.dip = iph->saddr;
+    p.sip = bridge_ip;
+    p.sport = bridge_port;
+
+    __u16 nat_port = 0;
+    struct udp_flow_info *flow_info =
+        bpf_map_lookup_elem(&ecnet_udp_flow, &flow);
+    if (flow_info) {
+        p.dport = flow_info->nat_port;
+        struct udp_origin_info *origin =
+            bpf_map_lookup_elem(&ecnet_udp_nat, &p);
+        if (origin && is_udp_origin(origin, &flow)) {
+            origin->last_seen = bpf_ktime_get_ns();
+            nat_port = p.dport;
+        }
+    }
+    if (nat_port) {
+        stat_inc(ECNET_STAT_UDP_NAT_HIT);
+    } else {
+        stat_inc(ECNET_STAT_UDP_NAT_MISS);
+        nat_port = reserve_udp_nat_port(&flow, &p);
+        if (!nat_port) {
+            debugf("ecnet_cni_udp_tc [egress]: no nat port left for %pI4:%d",
+                   &flow.sip, bpf_ntohs(flow.sport));
+            return TC_ACT_SHOT;
+        }
+        debugf("ecnet_cni_udp_tc [egress]: STORE Pair dip: %pI4 dport: %d",
+               &p.dip, bpf_ntohs(p.dport));
+        debugf("ecnet_cni_udp_tc [egress]: STORE Origin ip: %pI4 port: %d",
+               &flow.dip, bpf_ntohs(flow.dport));
+    }
+
+    __u32 udp_csum_off = UDP_CSUM_OFF;
+    __u32 udp_sport_off = UDP_SPORT_OFF;
+    __u32 udp_dport_off = UDP_DPORT_OFF;
+    __u16 sport = udph->source;
+    __u16 dport = udph->dest;
+    __u32 daddr = iph->daddr;
+
+    bpf_l4_csum_replace(skb, udp_csum_off, dport, bridge_port, sizeof(dport));
+    bpf_skb_store_bytes(skb, udp_dport_off, &bridge_port, sizeof(bridge_port),
+                        0);
+
+    if (nat_port != sport) {
+        bpf_l4_csum_replace(skb, udp_csum_off, sport, nat_port, sizeof(sport));
+        bpf_skb_store_bytes(skb, udp_sport_off, &nat_port, sizeof(nat_port),
+                            0);
+    }
+
+    // the ClusterSetIP imports are resolved to their virtual IP
+    if (daddr != bridge_ip) {
+        __u32 ip_csum_off = IP_CSUM_OFF;
+        __u32 daddr_off = IP_DST_OFF;
+        bpf_l4_csum_replace(skb, udp_csum_off, daddr, bridge_ip,
+                            IS_PSEUDO | sizeof(daddr));
+        bpf_l3_csum_replace(skb, ip_csum_off, daddr, bridge_ip, sizeof(daddr));
+        bpf_skb_store_bytes(skb, daddr_off, &bridge_ip, sizeof(bridge_ip), 0);
+    }
+
+    debugf("ecnet_cni_udp_tc [egress]: DNAT %pI4 -> %pI4", &daddr, &bridge_ip);
+    debugf("ecnet_cni_udp_tc [egress]: DNAT %d -> %d", bpf_ntohs(dport),
+           bpf_ntohs(bridge_port));
+    stat_inc(ECNET_STAT_EGRESS_REWRITE);
     return TC_ACT_OK;
 }
 
 static inline int process_udp_egress_packet(struct __sk_buff *skb,
+                                            struct ecnet_cfg *cfg,
                                             struct iphdr *iph, void *data_end)
 {
     struct udphdr *udph = (struct udphdr *)(iph + 1);
@@ -313,17 +565,23 @@ static inline int process_udp_egress_packet(struct __sk_buff *skb,
         return TC_ACT_SHOT;
     }
 
-    __u16 dns_port = bpf_htons(DNS_CAPTURE_PORT);
+    __u16 dns_port = bpf_htons(cfg->dns_capture_port);
     if (udph->dest != dns_port) {
+        // the udp services imported through the bridge resolve to the bridge,
+        // or to their virtual IP
+        if (iph->daddr == bpf_htonl(cfg->bridge_ip) ||
+            is_clusterset_vip(cfg, iph->daddr)) {
+            return process_udp_svc_egress_packet(skb, cfg, iph, udph);
+        }
         return TC_ACT_OK;
     }
 
-    __u32 bridge_ip = bpf_htonl(BRIDGE_IP);
+    __u32 bridge_ip = bpf_htonl(cfg->bridge_ip);
     if (iph->daddr == bridge_ip && udph->dest == dns_port) {
         return TC_ACT_OK;
     }
 
-    __u16 bridge_port = bpf_htons(DNS_PROXY_PORT);
+    __u16 bridge_port = bpf_htons(cfg->dns_proxy_port);
 
     struct pair p;
     memset(&p, 0, sizeof(p));
@@ -332,7 +590,6 @@ static inline int process_udp_egress_packet(struct __sk_buff *skb,
     p.dport = udph->source;
     p.sport = bridge_port;
 
-#ifdef DEBUG
     debugf("---------------------------------------------------------");
     debugf("mcs_cni_udp_tc [egress]: SRC ip: %pI4 port: %d", &iph->saddr,
            bpf_ntohs(udph->source));
@@ -342,18 +599,19 @@ static inline int process_udp_egress_packet(struct __sk_buff *skb,
            bpf_ntohs(p.sport));
     debugf("mcs_cni_udp_tc [egress]: STORE Pair dip: %pI4 dport: %d", &p.dip,
            bpf_ntohs(p.dport));
-#endif
 
     struct origin_info origin;
     memset(&origin, 0, sizeof(origin));
     origin.ip = iph->daddr;
     origin.port = udph->dest;
+    origin.proto = IPPROTO_UDP;
+    origin.last_seen = bpf_ktime_get_ns();
 
-#ifdef DEBUG
     debugf("mcs_cni_udp_tc [egress]: STORE Origin ip: %pI4 port: %d",
            &origin.ip, bpf_ntohs(origin.port));
-#endif
-    bpf_map_update_elem(&ecnet_dns_nat, &p, &origin, BPF_NOEXIST);
+    if (!bpf_map_update_elem(&ecnet_dns_nat, &p, &origin, BPF_NOEXIST)) {
+        stat_inc(ECNET_STAT_DNS_NAT_INSERT);
+    }
 
     __u32 udp_csum_off = UDP_CSUM_OFF;
     __u32 udp_dport_off = UDP_DPORT_OFF;
@@ -371,14 +629,15 @@ static inline int process_udp_egress_packet(struct __sk_buff *skb,
     bpf_skb_store_bytes(skb, udp_dport_off, &bridge_port, sizeof(bridge_port),
                         0);
 
-#ifdef DEBUG
     debugf("mcs_cni_udp_tc [egress]: DNAT %pI4 -> %pI4", &origin.ip,
            &bridge_ip);
-#endif
+    stat_inc(ECNET_STAT_DNS_REDIRECT);
+    stat_inc(ECNET_STAT_EGRESS_REWRITE);
     return TC_ACT_OK;
 }
 
-__section("classifier_egress") int ecnet_cni_tc_egress(struct __sk_buff *skb)
+static inline int process_egress_packet(struct __sk_buff *skb,
+                                        struct ecnet_cfg *cfg)
 {
     void *data = (void *)(long)skb->data;
     void *data_end = (void *)(long)skb->data_end;
@@ -402,9 +661,9 @@ __section("classifier_egress") int ecnet_cni_tc_egress(struct __sk_buff *skb)
             }
         }
         if (iph->protocol == IPPROTO_TCP) {
-            return process_tcp_egress_packet(skb, iph, data_end);
+            return process_tcp_egress_packet(skb, cfg, iph, data_end);
         } else if (iph->protocol == IPPROTO_UDP) {
-            return process_udp_egress_packet(skb, iph, data_end);
+            return process_udp_egress_packet(skb, cfg, iph, data_end);
         }
         return TC_ACT_OK;
     }
@@ -413,5 +672,19 @@ __section("classifier_egress") int ecnet_cni_tc_egress(struct __sk_buff *skb)
     }
 }
 
+__section("classifier_egress") int ecnet_cni_tc_egress(struct __sk_buff *skb)
+{
+    struct ecnet_cfg *cfg = get_config();
+    if (!cfg) {
+        return TC_ACT_OK;
+    }
+
+    int ret = process_egress_packet(skb, cfg);
+    if (ret == TC_ACT_SHOT) {
+        stat_inc(ECNET_STAT_EGRESS_DROP);
+    }
+    return ret;
+}
+
 char ____license[] __section("license") = "GPL";
 int _version __section("version") = 1;
\ No newline at end of file
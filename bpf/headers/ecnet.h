@@ -1,17 +1,13 @@
 #pragma once
-#ifndef ECNET_PROXY_PORT
-#define ECNET_PROXY_PORT 15001
-#endif
 
-#ifndef DNS_CAPTURE_PORT
-#define DNS_CAPTURE_PORT 53
-#endif
+#include <linux/types.h>
 
-#ifndef DNS_PROXY_PORT
-#define DNS_PROXY_PORT 15053
-#endif
+// The datapath parameters (bridge ip, proxy ports, ...) are read from the
+// ecnet_config map at runtime, see maps.h
 
-#ifndef BRIDGE_IP
-// 10.244.2.0
-#define BRIDGE_IP 183763456
-#endif
+// The constants below are rewritten by ecnet-bridge when loading the
+// precompiled objects, they must keep their names in sync with helpers/bpf.go
+
+// Terminates the traces with a newline, for the kernels older than 5.9 which
+// do not append it
+volatile const __u8 ecnet_printk_newline = 0;
@@ -110,38 +110,40 @@ static long (*bpf_skb_store_bytes)(struct __sk_buff *skb, __u32 offset,
 static unsigned long long (*bpf_ktime_get_ns)(void) = (void *)
     BPF_FUNC_ktime_get_ns;
 
+static __u32 (*bpf_get_prandom_u32)(void) = (void *)BPF_FUNC_get_prandom_u32;
+
 static int (*bpf_xdp_adjust_tail)(void *ctx, int offset) = (void *)
     BPF_FUNC_xdp_adjust_tail;
 
-#ifdef PRINTNL
-#define PRINT_SUFFIX "\n"
-#else
-#define PRINT_SUFFIX ""
-#endif
-
-#ifndef printk
-#define printk(fmt, ...)                                                       \
+#define __printk(prefix, fmt, ...)                                             \
     ({                                                                         \
-        char ____fmt[] = fmt PRINT_SUFFIX;                                     \
-        bpf_trace_printk(____fmt, sizeof(____fmt), ##__VA_ARGS__);             \
+        if (ecnet_printk_newline) {                                            \
+            char ____fmt[] = prefix fmt "\n";                                  \
+            bpf_trace_printk(____fmt, sizeof(____fmt), ##__VA_ARGS__);         \
+        } else {                                                               \
+            char ____fmt[] = prefix fmt;                                       \
+            bpf_trace_printk(____fmt, sizeof(____fmt), ##__VA_ARGS__);         \
+        }                                                                      \
     })
+
+#ifndef printk
+#define printk(fmt, ...) __printk("", fmt, ##__VA_ARGS__)
 #endif
 
-#ifndef DEBUG
-// do nothing
-#define debugf(fmt, ...) ({})
-#else
-// only print traceing in debug mode
+// returns whether the debug traces are enabled, defined in maps.h
+static inline int debug_enabled(void);
+
+// only print traceing in debug mode, it is switched at runtime through the
+// ecnet_config map
 #ifndef debugf
 #define debugf(fmt, ...)                                                       \
     ({                                                                         \
-        char ____fmt[] = "[debug] " fmt PRINT_SUFFIX;                          \
-        bpf_trace_printk(____fmt, sizeof(____fmt), ##__VA_ARGS__);             \
+        if (debug_enabled()) {                                                 \
+            __printk("[debug] ", fmt, ##__VA_ARGS__);                          \
+        }                                                                      \
     })
 #endif
 
-#endif
-
 #ifndef memset
 #define memset(dst, src, len) __builtin_memset(dst, src, len)
 #endif
@@ -12,6 +12,25 @@ struct pair {
 struct origin_info {
     __u32 ip;
     __u16 port;
+    __u16 proto;
+    // bpf_ktime_get_ns() of the last packet, used to expire idle udp entries
+    __u64 last_seen;
+};
+
+// The original destination of a udp flow redirected to the bridge proxy
+struct udp_origin_info {
+    __u32 ip;
+    __u16 port;
+    // the port of the pod socket, the flow is seen by the bridge proxy from
+    // another port when the socket sends to several destinations at once
+    __u16 sport;
+    // bpf_ktime_get_ns() of the last packet, used to expire idle entries
+    __u64 last_seen;
+};
+
+// The port a udp flow is seen from by the bridge proxy
+struct udp_flow_info {
+    __u16 nat_port;
     __u16 _pad;
 };
 
@@ -21,6 +40,49 @@ struct service_info {
     __u16 _pad;
 };
 
+// The datapath parameters, written by ecnet-bridge at startup and updated live.
+// All the fields are in host byte order, they must be kept in sync with
+// DatapathConfig of helpers/datapath.go
+struct ecnet_cfg {
+    __u32 bridge_ip;
+    // the virtual IPs of the ClusterSetIP imports, a zero mask disables them
+    __u32 clusterset_vip_net;
+    __u32 clusterset_vip_mask;
+    __u16 proxy_port;
+    __u16 udp_proxy_port;
+    __u16 dns_proxy_port;
+    __u16 dns_capture_port;
+    // enables the debug traces of the programs
+    __u8 debug;
+    __u8 _pad[3];
+};
+
+struct bpf_elf_map __section("maps") ecnet_config = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .size_key = sizeof(__u32),
+    .size_value = sizeof(struct ecnet_cfg),
+    .max_elem = 1,
+    .pinning = PIN_GLOBAL_NS,
+};
+
+// get_config returns the datapath parameters, NULL until ecnet-bridge wrote them
+static inline struct ecnet_cfg *get_config(void)
+{
+    __u32 key = 0;
+    struct ecnet_cfg *cfg = bpf_map_lookup_elem(&ecnet_config, &key);
+    if (!cfg || !cfg->bridge_ip) {
+        return NULL;
+    }
+    return cfg;
+}
+
+static inline int debug_enabled(void)
+{
+    __u32 key = 0;
+    struct ecnet_cfg *cfg = bpf_map_lookup_elem(&ecnet_config, &key);
+    return cfg && cfg->debug;
+}
+
 struct bpf_elf_map __section("maps") ecnet_dns_nat = {
     .type = BPF_MAP_TYPE_LRU_HASH,
     .size_key = sizeof(struct pair),
@@ -35,4 +97,81 @@ struct bpf_elf_map __section("maps") ecnet_svc_nat = {
     .size_value = sizeof(struct origin_info),
     .max_elem = 65535,
     .pinning = PIN_GLOBAL_NS,
-};
\ No newline at end of file
+};
+
+// The udp flows redirected to the bridge proxy, keyed by the pair of the
+// replies: bridge ip:udp proxy port -> pod ip:nat port
+struct bpf_elf_map __section("maps") ecnet_udp_nat = {
+    .type = BPF_MAP_TYPE_LRU_HASH,
+    .size_key = sizeof(struct pair),
+    .size_value = sizeof(struct udp_origin_info),
+    .max_elem = 65535,
+    .pinning = PIN_GLOBAL_NS,
+};
+
+// The nat ports of the udp flows, keyed by the pair of the datagrams sent by
+// the pods: pod ip:port -> original destination ip:port
+struct bpf_elf_map __section("maps") ecnet_udp_flow = {
+    .type = BPF_MAP_TYPE_LRU_HASH,
+    .size_key = sizeof(struct pair),
+    .size_value = sizeof(struct udp_flow_info),
+    .max_elem = 65535,
+    .pinning = PIN_GLOBAL_NS,
+};
+
+// The counters of ecnet_stats. The map is pinned and kept across the upgrades
+// of ecnet-bridge, so the indexes are only ever appended, below
+// ECNET_STATS_MAX. They must be kept in sync with helpers/metrics.go
+enum ecnet_stat {
+    // packets from the bridge proxy, or to it, without nat entry
+    ECNET_STAT_SVC_NAT_MISS = 0,
+    // replies of the bridge DNS proxy without nat entry
+    ECNET_STAT_DNS_NAT_MISS = 1,
+    // packets from the bridge proxy, or to it, whose nat entry was found
+    ECNET_STAT_SVC_NAT_HIT = 2,
+    // replies of the bridge DNS proxy whose nat entry was found
+    ECNET_STAT_DNS_NAT_HIT = 3,
+    // packets whose source was rewritten on ingress
+    ECNET_STAT_INGRESS_REWRITE = 4,
+    // packets whose destination was rewritten on egress, DNS queries included
+    ECNET_STAT_EGRESS_REWRITE = 5,
+    // DNS queries redirected to the bridge DNS proxy
+    ECNET_STAT_DNS_REDIRECT = 6,
+    // getsockopt(SO_ORIGINAL_DST) answered with the original destination
+    ECNET_STAT_ORIGINAL_DST_REWRITE = 7,
+    // packets dropped with TC_ACT_SHOT on ingress
+    ECNET_STAT_INGRESS_DROP = 8,
+    // packets dropped with TC_ACT_SHOT on egress
+    ECNET_STAT_EGRESS_DROP = 9,
+    // udp datagrams from the bridge proxy, or to it, without nat entry
+    ECNET_STAT_UDP_NAT_MISS = 10,
+    // udp datagrams from the bridge proxy, or to it, whose nat entry was found
+    ECNET_STAT_UDP_NAT_HIT = 11,
+    // entries inserted into the nat maps, the entries evicted by the LRU are
+    // derived from them by the cni controller
+    ECNET_STAT_SVC_NAT_INSERT = 12,
+    ECNET_STAT_DNS_NAT_INSERT = 13,
+    ECNET_STAT_UDP_NAT_INSERT = 14,
+    // entries of ecnet_svc_nat deleted once the connection is closed
+    ECNET_STAT_SVC_NAT_DELETE = 15,
+};
+
+#define ECNET_STATS_MAX 64
+
+struct bpf_elf_map __section("maps") ecnet_stats = {
+    .type = BPF_MAP_TYPE_PERCPU_ARRAY,
+    .size_key = sizeof(__u32),
+    .size_value = sizeof(__u64),
+    .max_elem = ECNET_STATS_MAX,
+    .pinning = PIN_GLOBAL_NS,
+};
+
+// stat_inc increments a counter of ecnet_stats, the slot of the current cpu
+// is only written by this cpu
+static inline void stat_inc(__u32 idx)
+{
+    __u64 *count = bpf_map_lookup_elem(&ecnet_stats, &idx);
+    if (count) {
+        *count += 1;
+    }
+}
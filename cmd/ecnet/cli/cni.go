@@ -20,6 +20,7 @@ func newCniCmd(config *action.Configuration, in io.Reader, out io.Writer) *cobra
 		Args:  cobra.NoArgs,
 	}
 	cmd.AddCommand(newCniList(out))
+	cmd.AddCommand(newCniStatus(out))
 
 	if !settings.IsManaged() {
 		cmd.AddCommand(newCniUpgradeCmd(config, out))
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s"
+)
+
+const cniStatusDescription = `
+This command lists the pods of each node along with whether their traffic is
+intercepted by the tc programs of ecnet-bridge, and whether the programs are attached.`
+
+// cniStatusTimeout is the timeout of the request for the pods status of a bridge
+const cniStatusTimeout = 10 * time.Second
+
+type cniStatusCmd struct {
+	out        io.Writer
+	restConfig *rest.Config
+	clientSet  kubernetes.Interface
+	all        bool
+}
+
+// bridgePodStatus is the attachment status of a pod served by ecnet-bridge
+type bridgePodStatus struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Intercepted bool   `json:"intercepted"`
+	Attached    bool   `json:"attached"`
+	Device      string `json:"device"`
+	NatEntries  int    `json:"natEntries"`
+	Error       string `json:"error"`
+}
+
+func newCniStatus(out io.Writer) *cobra.Command {
+	statusCmd := &cniStatusCmd{
+		out: out,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "list the interception status of the pods of each node",
+		Long:  cniStatusDescription,
+		Args:  cobra.ExactArgs(0),
+		RunE: func(_ *cobra.Command, args []string) error {
+			config, err := settings.RESTClientGetter().ToRESTConfig()
+			if err != nil {
+				return fmt.Errorf("Error fetching kubeconfig: %w", err)
+			}
+			clientset, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				return fmt.Errorf("Could not access Kubernetes cluster, check kubeconfig: %w", err)
+			}
+			statusCmd.restConfig = config
+			statusCmd.clientSet = clientset
+			return statusCmd.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVarP(&statusCmd.all, "all", "a", false, "also list the pods whose traffic is not intercepted")
+
+	return cmd
+}
+
+func (c *cniStatusCmd) run() error {
+	labelSelector := labels.Set{constants.AppLabel: constants.ECNETBridgeName}.String()
+	bridgePods, err := c.clientSet.CoreV1().Pods(settings.Namespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return fmt.Errorf("Could not list %s pods in namespace [%s]: %w", constants.ECNETBridgeName, settings.Namespace(), err)
+	}
+	if len(bridgePods.Items) == 0 {
+		fmt.Fprintf(c.out, "No %s pods found in namespace [%s]\n", constants.ECNETBridgeName, settings.Namespace())
+		return nil
+	}
+
+	w := newTabWriter(c.out)
+	fmt.Fprint(w, "NODE\tNAMESPACE\tPOD\tINTERCEPTED\tATTACHED\tDEVICE\tNAT ENTRIES\tERROR\n")
+	for _, bridgePod := range bridgePods.Items {
+		statuses, err := c.getPodsStatus(bridgePod.Name, bridgePod.Namespace)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t\t\t\t\t\t\t%v\n", bridgePod.Spec.NodeName, err)
+			continue
+		}
+		for _, st := range statuses {
+			if !st.Intercepted && !st.Attached && !c.all {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%t\t%s\t%d\t%s\n",
+				bridgePod.Spec.NodeName, st.Namespace, st.Name, st.Intercepted, st.Attached, st.Device, st.NatEntries, st.Error)
+		}
+	}
+	return w.Flush()
+}
+
+// getPodsStatus gets the pods status from the bridge pod through port forwarding,
+// the bridge only serves it on the loopback interface of its node
+func (c *cniStatusCmd) getPodsStatus(pod string, namespace string) ([]bridgePodStatus, error) {
+	dialer, err := k8s.DialerToPod(c.restConfig, c.clientSet, pod, namespace)
+	if err != nil {
+		return nil, err
+	}
+	portForwarder, err := k8s.NewPortForwarder(dialer, fmt.Sprintf("0:%d", constants.ECNETBridgeStatusServerPort))
+	if err != nil {
+		return nil, fmt.Errorf("Error setting up port forwarding to pod [%s] in namespace [%s]: %w", pod, namespace, err)
+	}
+
+	var statuses []bridgePodStatus
+	err = portForwarder.Start(func(pf *k8s.PortForwarder) error {
+		defer pf.Stop()
+		localPort, err := pf.LocalPort()
+		if err != nil {
+			return err
+		}
+		httpc := http.Client{Timeout: cniStatusTimeout}
+		resp, err := httpc.Get(fmt.Sprintf("http://localhost:%d%s", localPort, constants.ECNETBridgePodsStatusPath))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close() //nolint: errcheck
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return json.NewDecoder(resp.Body).Decode(&statuses)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving pods status from pod [%s] in namespace [%s]: %w", pod, namespace, err)
+	}
+	return statuses, nil
+}
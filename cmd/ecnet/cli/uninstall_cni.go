@@ -262,6 +262,7 @@ func (d *uninstallCniCmd) uninstallClusterResources() []string {
 func (d *uninstallCniCmd) uninstallCustomResourceDefinitions() error {
 	crds := []string{
 		"ecnetconfigs.config.flomesh.io",
+		"plugins.config.flomesh.io",
 	}
 
 	var failedDeletions []string
@@ -2,11 +2,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"os"
 	"path"
+	"time"
 
 	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -16,15 +19,26 @@ import (
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/cniserver"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/helpers"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/podwatcher"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/cri"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/configurator"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+	configClientset "github.com/flomesh-io/ErieCanal/pkg/ecnet/gen/client/config/clientset/versioned"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/httpserver"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s/events"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s/informers"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/messaging"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/metricsstore"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/version"
 )
 
 var (
-	verbosity      string
-	kubeConfigFile string
-	ecnetVersion   string
+	verbosity       string
+	kubeConfigFile  string
+	ecnetName       string // An ID that uniquely identifies an ECNET instance
+	ecnetNamespace  string
+	ecnetConfigName string
+	ecnetVersion    string
 
 	scheme = runtime.NewScheme()
 
@@ -35,16 +49,27 @@ var (
 func init() {
 	flags.StringVarP(&verbosity, "verbosity", "v", "info", "Set log verbosity level")
 	flags.StringVar(&kubeConfigFile, "kubeconfig", "", "Path to Kubernetes config file.")
+	flags.StringVar(&ecnetName, "ecnet-name", "", "ecnet name")
+	flags.StringVar(&ecnetNamespace, "ecnet-namespace", "", "ecnet controller's namespace")
+	flags.StringVar(&ecnetConfigName, "ecnet-config-name", "ecnet-config", "Name of the ecnet Config")
 	flags.StringVar(&ecnetVersion, "ecnet-version", "", "Version of ECNET")
 
 	// Get some flags from commands
-	flags.BoolVarP(&config.KernelTracing, "kernel-tracing", "d", false, "kernel tracing mode")
 	flags.BoolVarP(&config.IsKind, "kind", "k", false, "Enable when Kubernetes is running in Kind")
 	flags.StringVar(&config.BridgeEth, "bridge-eth", "cni0", "bridge veth created by CNI")
 	flags.StringVar(&config.HostProc, "host-proc", "/host/proc", "/proc mount path")
 	flags.StringVar(&config.CNIBinDir, "cni-bin-dir", "/host/opt/cni/bin", "/opt/cni/bin mount path")
 	flags.StringVar(&config.CNIConfigDir, "cni-config-dir", "/host/etc/cni/net.d", "/etc/cni/net.d mount path")
 	flags.StringVar(&config.HostVarRun, "host-var-run", "/host/var/run", "/var/run mount path")
+	flags.StringSliceVar(&config.ExcludeNamespaces, "exclude-namespaces", nil, "namespaces whose pods are never intercepted, kube-system and the ecnet namespace when not set")
+	flags.BoolVar(&config.InterceptByDefault, "intercept-by-default", true, "intercept the pods when neither they nor their namespace have the sidecar injection annotation")
+	flags.StringVar(&config.CRIEndpoint, "cri-endpoint", "", "CRI socket of the container runtime, detected under --host-var-run when empty")
+	flags.DurationVar(&config.UDPNatIdleTimeout, "udp-nat-idle-timeout", 60*time.Second, "idle timeout of the original destination of udp flows")
+	flags.DurationVar(&config.TCPNatIdleTimeout, "tcp-nat-idle-timeout", 5*24*time.Hour, "idle timeout of the original destination of tcp connections, as long as conntrack keeps established ones")
+	flags.Uint32Var(&config.SvcNatMapSize, "svc-nat-map-size", 65535, "max entries of the nat map of the imported services")
+	flags.Uint32Var(&config.DNSNatMapSize, "dns-nat-map-size", 1024, "max entries of the nat map of the DNS queries")
+	flags.StringVar(&config.BPFObjectsDir, "bpf-objects-dir", "/ec/bpf", "directory of the precompiled ebpf objects")
+	flags.StringVar(&config.CGroup2Path, "cgroup2-path", "", "cgroup2 mount path, detected when empty")
 
 	_ = clientgoscheme.AddToScheme(scheme)
 }
@@ -59,6 +84,9 @@ func parseFlags() error {
 
 // validateCLIParams contains all checks necessary that various permutations of the CLI flags are consistent
 func validateCLIParams() error {
+	if len(ecnetNamespace) == 0 {
+		return errors.New("Please specify the ECNET namespace using --ecnet-namespace")
+	}
 	return nil
 }
 
@@ -76,24 +104,77 @@ func main() {
 		events.GenericEventRecorder().FatalEvent(err, events.InvalidCLIParameters, "Error validating CLI parameters")
 	}
 
+	if !flags.Changed("exclude-namespaces") {
+		config.ExcludeNamespaces = []string{metav1.NamespaceSystem, ecnetNamespace}
+	}
+
 	// Initialize kube config and client
 	kubeConfig, err := clientcmd.BuildConfigFromFlags("", kubeConfigFile)
 	if err != nil {
 		log.Fatal().Err(err).Msgf("Error creating kube config (kubeconfig=%s)", kubeConfigFile)
 	}
 	kubeClient := kubernetes.NewForConfigOrDie(kubeConfig)
+	configClient := configClientset.NewForConfigOrDie(kubeConfig)
 
-	if err = helpers.LoadProgs(config.KernelTracing); err != nil {
+	if err = helpers.LoadProgs(); err != nil {
 		log.Fatal().Msgf("failed to load ebpf programs: %v", err)
 	}
 
 	stop := make(chan struct{}, 1)
+	msgBroker := messaging.NewBroker(stop)
+
+	informerCollection, err := informers.NewInformerCollection(ecnetName, stop,
+		informers.WithConfigClient(configClient, ecnetConfigName, ecnetNamespace),
+	)
+	if err != nil {
+		events.GenericEventRecorder().FatalEvent(err, events.InitializationError, "Error creating informer collection")
+	}
+
+	// The datapath parameters of the ebpf programs are read from EcnetConfig
+	cfg := configurator.NewConfigurator(informerCollection, ecnetNamespace, ecnetConfigName, "", msgBroker)
+	if err = helpers.SyncDatapathConfig(cfg, msgBroker, stop); err != nil {
+		log.Fatal().Msgf("failed to write datapath config: %v", err)
+	}
+
 	cniReady := make(chan struct{}, 1)
-	s := cniserver.NewServer(path.Join("/host", config.CNISock), "/sys/fs/bpf", cniReady, stop)
+	// The pods are intercepted according to their annotations and the ones of their namespace
+	interception, err := podwatcher.NewInterception(kubeClient, stop)
+	if err != nil {
+		log.Fatal().Msgf("failed to watch pods: %v", err)
+	}
+	// The netns of the pods are resolved through the container runtime, falling back to scanning the host processes
+	var resolver cniserver.NetnsResolver
+	if criClient, err := cri.NewClient(config.CRIEndpoint); err != nil {
+		log.Warn().Msgf("failed to connect to the container runtime, netns of the pods resolved from %s: %v", config.HostProc, err)
+	} else {
+		defer criClient.Close() //nolint: errcheck
+		resolver = criClient
+	}
+	s := cniserver.NewServer(path.Join("/host", config.CNISock), "/sys/fs/bpf", interception, resolver, cniReady, stop)
 	if err = s.Start(); err != nil {
 		log.Fatal().Err(err)
 	}
-	if err = podwatcher.Run(kubeClient, stop); err != nil {
+
+	// Initialize ecnet-bridge's http service server
+	metricsstore.DefaultMetricsStore.Start(
+		metricsstore.DefaultMetricsStore.BridgeNatEvictionCounter,
+		helpers.DatapathMetricsCollector(),
+	)
+	httpServer := httpserver.NewHTTPServer(constants.ECNETBridgeHTTPServerPort)
+	httpServer.AddHandler(constants.MetricsPath, metricsstore.DefaultMetricsStore.Handler())
+	httpServer.AddHandler(constants.VersionPath, version.GetVersionHandler())
+	if err = httpServer.Start(); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to start ecnet-bridge HTTP server")
+	}
+
+	// The bridge runs on the host network, the status of the pods is only served locally, reached by port forwarding
+	statusServer := httpserver.NewLocalHTTPServer(constants.ECNETBridgeStatusServerPort)
+	statusServer.AddHandler(constants.ECNETBridgePodsStatusPath, cniserver.PodsStatusHandler(s))
+	if err = statusServer.Start(); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to start ecnet-bridge status server")
+	}
+
+	if err = podwatcher.Run(interception, s, ecnetNamespace, stop); err != nil {
 		log.Fatal().Err(err)
 	}
 	log.Info().Msgf("Stopping ecnet-bridge %s; %s; %s", version.Version, version.GitCommit, version.BuildDate)
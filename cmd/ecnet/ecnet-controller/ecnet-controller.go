@@ -35,6 +35,7 @@ import (
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s/informers"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/messaging"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/metricsstore"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/proxyserver/registry"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/proxyserver/server"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
@@ -52,6 +53,7 @@ var (
 	ecnetNamespace      string
 	ecnetServiceAccount string
 	ecnetConfigName     string
+	remoteLoggingSecret string
 	ecnetVersion        string
 	trustDomain         string
 
@@ -69,6 +71,7 @@ func init() {
 	flags.StringVar(&ecnetNamespace, "ecnet-namespace", "", "ecnet controller's namespace")
 	flags.StringVar(&ecnetServiceAccount, "ecnet-service-account", "", "ecnet controller's service account")
 	flags.StringVar(&ecnetConfigName, "ecnet-config-name", "ecnet-config", "Name of the ecnet Config")
+	flags.StringVar(&remoteLoggingSecret, "remote-logging-secret", "", "Name of the Secret in ecnet controller's namespace given to the bridges for the authorization of remote logging")
 	flags.StringVar(&ecnetVersion, "ecnet-version", "", "Version of ecnet")
 
 	// TODO (#4502): Remove when we add full MRC support
@@ -121,6 +124,7 @@ func main() {
 
 	informerCollection, err := informers.NewInformerCollection(ecnetName, stop,
 		informers.WithKubeClient(kubeClient),
+		informers.WithCodebaseOverlayClient(kubeClient, ecnetNamespace),
 		informers.WithConfigClient(configClient, ecnetConfigName, ecnetNamespace),
 		informers.WithMultiClusterClient(multiclusterClient),
 	)
@@ -129,7 +133,7 @@ func main() {
 	}
 
 	// This component will be watching resources in the config.flomesh.io API group
-	cfg := configurator.NewConfigurator(informerCollection, ecnetNamespace, ecnetConfigName, msgBroker)
+	cfg := configurator.NewConfigurator(informerCollection, ecnetNamespace, ecnetConfigName, remoteLoggingSecret, msgBroker)
 	k8sClient := k8s.NewKubernetesController(informerCollection, msgBroker)
 	multiclusterController := multicluster.NewMultiClusterController(informerCollection, kubeClient, k8sClient, msgBroker)
 	kubeProvider := kube.NewClient(k8sClient, cfg)
@@ -149,14 +153,30 @@ func main() {
 
 	proxyRegistry := registry.NewProxyRegistry(msgBroker)
 	// Create and start the pipy repo http service
-	repoServer := server.NewRepoServer(meshCatalog, proxyRegistry, ecnetNamespace, cfg, k8sClient, msgBroker)
+	repoServer := server.NewRepoServer(meshCatalog, proxyRegistry, ecnetNamespace, cfg, k8sClient, kubeClient, msgBroker)
 	// Create and start the proxy service
 	if err = repoServer.Start(cfg.GetProxyServerPort()); err != nil {
 		events.GenericEventRecorder().FatalEvent(err, events.InitializationError, "Error initializing proxy control server")
 	}
 
+	// Start the default metrics store
+	metricsstore.DefaultMetricsStore.Start(
+		metricsstore.DefaultMetricsStore.EventCounter,
+		metricsstore.DefaultMetricsStore.ProxyUpdateEventCounter,
+		metricsstore.DefaultMetricsStore.ProxyUpdateDispatchCounter,
+		metricsstore.DefaultMetricsStore.ProxyUpdateBatchSize,
+		metricsstore.DefaultMetricsStore.ProxyConfigUpdateTime,
+		metricsstore.DefaultMetricsStore.ProxyConfigSize,
+		metricsstore.DefaultMetricsStore.RepoAPIRequestTime,
+		metricsstore.DefaultMetricsStore.RepoAPIErrorCounter,
+		metricsstore.DefaultMetricsStore.ErrCodeCounter,
+		multiclusterController.MetricsCollector(),
+	)
+
 	// Initialize ECNET's http service server
 	httpServer := httpserver.NewHTTPServer(constants.ECNETHTTPServerPort)
+	// Metrics
+	httpServer.AddHandler(constants.MetricsPath, metricsstore.DefaultMetricsStore.Handler())
 	// Health/Liveness probes
 	funcProbes := []health.Probes{repoServer}
 	httpServer.AddHandlers(map[string]http.Handler{
@@ -175,6 +195,9 @@ func main() {
 	// Start the global log level watcher that updates the log level dynamically
 	go k8s.WatchAndUpdateLogLevel(msgBroker, stop)
 
+	// Start the allocation of the virtual IPs of the ClusterSetIP imports
+	go multiclusterController.WatchAndAllocateClusterSetIPs(multiclusterClient, cfg, msgBroker, stop)
+
 	<-stop
 	cancel()
 	log.Info().Msgf("Stopping ecnet-controller %s; %s; %s", version.Version, version.GitCommit, version.BuildDate)
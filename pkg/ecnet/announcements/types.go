@@ -66,6 +66,17 @@ const (
 	// ServiceAccountUpdated is the type of announcement emitted when we observe an update to a Kubernetes Service
 	ServiceAccountUpdated Kind = "serviceaccount-updated"
 
+	// ---
+
+	// CodebaseOverlayAdded is the type of announcement emitted when we observe an addition of a codebase overlay ConfigMap
+	CodebaseOverlayAdded Kind = "codebaseoverlay-added"
+
+	// CodebaseOverlayDeleted the type of announcement emitted when we observe the deletion of a codebase overlay ConfigMap
+	CodebaseOverlayDeleted Kind = "codebaseoverlay-deleted"
+
+	// CodebaseOverlayUpdated is the type of announcement emitted when we observe an update to a codebase overlay ConfigMap
+	CodebaseOverlayUpdated Kind = "codebaseoverlay-updated"
+
 	// --- config.flomesh.io API events
 
 	// EcnetConfigAdded is the type of announcement emitted when we observe an addition of a Kubernetes EcnetConfig
@@ -77,6 +88,15 @@ const (
 	// EcnetConfigUpdated is the type of announcement emitted when we observe an update to a Kubernetes EcnetConfig
 	EcnetConfigUpdated Kind = "ecnetconfig-updated"
 
+	// PluginAdded is the type of announcement emitted when we observe an addition of a Kubernetes Plugin
+	PluginAdded Kind = "plugin-added"
+
+	// PluginDeleted the type of announcement emitted when we observe the deletion of a Kubernetes Plugin
+	PluginDeleted Kind = "plugin-deleted"
+
+	// PluginUpdated is the type of announcement emitted when we observe an update to a Kubernetes Plugin
+	PluginUpdated Kind = "plugin-updated"
+
 	// --- policy.flomesh.io API events
 
 	// ServiceImportAdded is the type of announcement emitted when we observe an addition of serviceimports.flomesh.io
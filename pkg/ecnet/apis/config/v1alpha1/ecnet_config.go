@@ -32,6 +32,50 @@ type EcnetConfigSpec struct {
 
 	// PluginChains defines the default plugin chains.
 	PluginChains PluginChainsSpec `json:"pluginChains,omitempty"`
+
+	// Observability defines the observability configurations of the proxy sidecar.
+	Observability ObservabilitySpec `json:"observability,omitempty"`
+
+	// ClusterSet defines the configurations of the services imported from the cluster set.
+	ClusterSet ClusterSetSpec `json:"clusterSet,omitempty"`
+
+	// Bridge defines the datapath configurations of ecnet-bridge.
+	Bridge BridgeSpec `json:"bridge,omitempty"`
+}
+
+// BridgeSpec is the type to represent ECNET's bridge datapath configurations.
+// The ports are shared by the ebpf programs and the bridge proxy, they are updated live, as is the kernel tracing.
+type BridgeSpec struct {
+	// ProxyPort defines the port the bridge proxy listens on for the tcp traffic of the imported services.
+	ProxyPort uint16 `json:"proxyPort,omitempty"`
+
+	// UDPProxyPort defines the port the bridge proxy listens on for the udp traffic of the imported services.
+	UDPProxyPort uint16 `json:"udpProxyPort,omitempty"`
+
+	// DNSProxyPort defines the port the bridge DNS proxy listens on.
+	DNSProxyPort uint16 `json:"dnsProxyPort,omitempty"`
+
+	// DNSCapturePort defines the destination port of the DNS queries redirected to the bridge DNS proxy.
+	DNSCapturePort uint16 `json:"dnsCapturePort,omitempty"`
+
+	// KernelTracing defines whether the ebpf programs print their debug traces to trace_pipe.
+	KernelTracing bool `json:"kernelTracing,omitempty"`
+}
+
+// ClusterSetSpec is the type to represent ECNET's cluster set configurations.
+type ClusterSetSpec struct {
+	// VIPCIDR defines the CIDR the virtual IPs of the ClusterSetIP imports are allocated from.
+	// The imports are resolved to the bridge IP if empty.
+	VIPCIDR string `json:"vipCIDR,omitempty"`
+}
+
+// ObservabilitySpec is the type to represent ECNET's observability configurations.
+type ObservabilitySpec struct {
+	// Tracing defines ECNET's tracing configuration.
+	Tracing TracingSpec `json:"tracing,omitempty"`
+
+	// RemoteLogging defines ECNET's remote logging configuration.
+	RemoteLogging RemoteLoggingSpec `json:"remoteLogging,omitempty"`
 }
 
 // LocalDNSProxy is the type to represent ECNET's local DNS proxy configuration.
@@ -44,6 +88,12 @@ type LocalDNSProxy struct {
 
 	// SecondaryUpstreamDNSServerIPAddr defines a secondary upstream DNS server for local DNS Proxy.
 	SecondaryUpstreamDNSServerIPAddr string `json:"secondaryUpstreamDNSServerIPAddr,omitempty"`
+
+	// TTL defines the time to live in seconds of the records answered by local DNS Proxy.
+	TTL uint32 `json:"ttl,omitempty"`
+
+	// NegativeTTL defines the time in seconds local DNS Proxy caches the names that do not exist.
+	NegativeTTL uint32 `json:"negativeTTL,omitempty"`
 }
 
 // SidecarSpec is the type used to represent the specifications for the proxy sidecar.
@@ -77,6 +127,9 @@ type TracingSpec struct {
 
 	// SampledFraction defines the sampled fraction.
 	SampledFraction *string `json:"sampledFraction,omitempty"`
+
+	// Propagation defines the trace context formats propagated across clusters, one of b3, w3c and b3+w3c.
+	Propagation string `json:"propagation,omitempty"`
 }
 
 // RemoteLoggingSpec is the type to represent ECNET's remote logging configuration.
@@ -93,11 +146,16 @@ type RemoteLoggingSpec struct {
 	// Endpoint defines the API endpoint for remote logging requests sent to the collector.
 	Endpoint string `json:"endpoint,omitempty"`
 
-	// Authorization defines the access entity that allows to authorize someone in remote logging service.
-	Authorization string `json:"authorization,omitempty"`
+	// AuthorizationSecret defines the key of the secret in ECNET's namespace holding the access entity
+	// that allows to authorize someone in remote logging service.
+	AuthorizationSecret *corev1.SecretKeySelector `json:"authorizationSecret,omitempty"`
 
 	// SampledFraction defines the sampled fraction.
 	SampledFraction *string `json:"sampledFraction,omitempty"`
+
+	// Fields defines the fields of the access log to be shipped, ex. trace.id or req.headers.
+	// All fields are shipped if empty.
+	Fields []string `json:"fields,omitempty"`
 }
 
 // IngressGatewayCertSpec is the type to represent the certificate specification for an ingress gateway.
@@ -0,0 +1,47 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Plugin is the type used to represent a custom pipy plugin.
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type Plugin struct {
+	// Object's type metadata.
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+
+	// Object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	// Spec is the Plugin specification.
+	// +optional
+	Spec PluginSpec `json:"spec,omitempty" yaml:"spec,omitempty"`
+}
+
+// PluginSpec is the type used to represent the Plugin specification.
+type PluginSpec struct {
+	// Script defines the pjs source of the plugin.
+	Script string `json:"script"`
+
+	// Chain defines the plugin chain the plugin is mounted to,
+	// one of inbound-tcp, inbound-http, outbound-tcp and outbound-http.
+	Chain string `json:"chain"`
+
+	// Priority defines the priority of plugin in the chain.
+	Priority float32 `json:"priority"`
+
+	// Disable defines the visibility of plugin
+	Disable bool `json:"disable,omitempty"`
+}
+
+// PluginList lists the Plugin objects.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PluginList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Plugin `json:"items"`
+}
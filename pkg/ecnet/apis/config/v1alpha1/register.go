@@ -39,6 +39,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&EcnetConfig{},
 		&EcnetConfigList{},
+		&Plugin{},
+		&PluginList{},
 	)
 
 	metav1.AddToGroupVersion(
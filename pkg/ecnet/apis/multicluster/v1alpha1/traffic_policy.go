@@ -56,6 +56,103 @@ type GlobalTrafficPolicySpec struct {
 
 	// +optional
 	LoadBalanceTarget []TrafficTarget `json:"targets"`
+
+	// RateLimit defines the rate limiting applied to the requests sent to the imported or exported service
+	// +optional
+	RateLimit *RateLimitSpec `json:"rateLimit,omitempty"`
+
+	// GRPCRoutes defines the routing of the gRPC requests sent to the imported service
+	// +optional
+	GRPCRoutes []GRPCRouteSpec `json:"grpcRoutes,omitempty"`
+}
+
+// RateLimitSpec defines the rate limiting applied to the requests sent to an imported or exported service
+type RateLimitSpec struct {
+	// RequestsPerSecond defines the number of requests allowed per second
+	RequestsPerSecond uint32 `json:"requestsPerSecond"`
+
+	// Burst defines the number of requests above the baseline rate that are allowed in a short period of time
+	// +optional
+	Burst uint32 `json:"burst,omitempty"`
+
+	// ResponseStatusCode defines the HTTP status code of the response to a rate limited request, defaults to 429
+	// +optional
+	ResponseStatusCode uint32 `json:"responseStatusCode,omitempty"`
+
+	// ResponseHeadersToAdd defines the headers added to the response to a rate limited request
+	// +optional
+	ResponseHeadersToAdd []HTTPHeaderValue `json:"responseHeadersToAdd,omitempty"`
+
+	// Routes defines the rate limiting overrides of the requests matching a route
+	// +optional
+	Routes []RouteRateLimitSpec `json:"routes,omitempty"`
+}
+
+// RouteRateLimitSpec defines the rate limiting applied to the requests matching a route
+type RouteRateLimitSpec struct {
+	// Path defines the regular expression matched against the path of the requests
+	Path string `json:"path"`
+
+	// Methods defines the methods of the requests, all methods are matched when empty
+	// +optional
+	Methods []string `json:"methods,omitempty"`
+
+	// RequestsPerSecond defines the number of requests allowed per second
+	RequestsPerSecond uint32 `json:"requestsPerSecond"`
+
+	// Burst defines the number of requests above the baseline rate that are allowed in a short period of time
+	// +optional
+	Burst uint32 `json:"burst,omitempty"`
+
+	// ResponseStatusCode defines the HTTP status code of the response to a rate limited request, defaults to 429
+	// +optional
+	ResponseStatusCode uint32 `json:"responseStatusCode,omitempty"`
+
+	// ResponseHeadersToAdd defines the headers added to the response to a rate limited request
+	// +optional
+	ResponseHeadersToAdd []HTTPHeaderValue `json:"responseHeadersToAdd,omitempty"`
+}
+
+// GRPCRouteSpec defines the routing of the gRPC requests matching a service and method
+type GRPCRouteSpec struct {
+	// Service defines the fully qualified name of the gRPC service, ex. helloworld.Greeter
+	Service string `json:"service"`
+
+	// Method defines the name of the gRPC method, all methods of the service are matched when empty
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Timeout defines the deadline of the requests matching the route
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// RetryPolicy defines the retry policy of the requests matching the route
+	// +optional
+	RetryPolicy *GRPCRetryPolicySpec `json:"retryPolicy,omitempty"`
+}
+
+// GRPCRetryPolicySpec defines the retry policy of gRPC requests, keyed on the gRPC status codes
+type GRPCRetryPolicySpec struct {
+	// RetryOn defines the gRPC status codes to retry on, ex. unavailable, resource-exhausted.
+	// Only the statuses returned without a response message, ex. trailers-only responses, are retried,
+	// the responses carrying messages are streamed through as is.
+	RetryOn []string `json:"retryOn"`
+
+	// NumRetries defines the max number of retries
+	NumRetries uint32 `json:"numRetries"`
+
+	// RetryBackoffBaseInterval defines the base interval of the exponential backoff between retries
+	// +optional
+	RetryBackoffBaseInterval *metav1.Duration `json:"retryBackoffBaseInterval,omitempty"`
+}
+
+// HTTPHeaderValue defines an HTTP header name/value pair
+type HTTPHeaderValue struct {
+	// Name defines the name of the HTTP header
+	Name string `json:"name"`
+
+	// Value defines the value of the HTTP header
+	Value string `json:"value"`
 }
 
 // GlobalTrafficPolicyStatus defines the observed state of GlobalTrafficPolicy
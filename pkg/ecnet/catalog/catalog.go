@@ -44,6 +44,5 @@ func (mc *MeshCatalog) GetKubeController() k8s.Controller {
 
 // GetTrustDomain returns the currently configured trust domain, ie: cluster.local
 func (mc *MeshCatalog) GetTrustDomain() string {
-	// TODO benne
-	return "cluster.local"
+	return k8s.GetTrustDomain()
 }
@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service/endpoint"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service/policy"
+)
+
+// addDNSRecords adds the address records over which the service is resolvable, and the SRV record
+// of its port when the port is named, ie: _http._tcp.service.namespace.svc.cluster.local
+// Imported services are also resolvable in the clusterset.local domain.
+// Only IPv4 addresses are recorded, the bridge intercepts IPv4 traffic alone, so AAAA queries get an empty answer.
+func (mc *MeshCatalog) addDNSRecords(dnsRecords map[string][]policy.DNSRecord, meshSvc service.MeshService, endpoints []endpoint.Endpoint) {
+	names := []string{meshSvc.FQDN()}
+	if meshSvc.IsMultiClusterService() {
+		names = append(names, meshSvc.ClusterSetFQDN())
+	}
+
+	for _, name := range names {
+		for _, endp := range endpoints {
+			if endp.IP.To4() == nil {
+				continue
+			}
+			addDNSRecord(dnsRecords, name, policy.DNSRecord{Type: policy.DNSRecordTypeA, Address: endp.IP.String()})
+		}
+	}
+
+	portName := mc.getServicePortName(meshSvc)
+	if len(portName) == 0 {
+		return
+	}
+	proto := "tcp"
+	if meshSvc.Protocol == constants.ProtocolUDP {
+		proto = "udp"
+	}
+
+	// The SRV record of a headless service points to each of its endpoint hostnames
+	parentSvc := service.MeshService{Namespace: meshSvc.Namespace, Name: meshSvc.ProviderKey()}
+	addDNSRecord(dnsRecords, fmt.Sprintf("_%s._%s.%s", portName, proto, parentSvc.FQDN()),
+		policy.DNSRecord{Type: policy.DNSRecordTypeSRV, Target: meshSvc.FQDN(), Port: meshSvc.Port})
+	if meshSvc.IsMultiClusterService() {
+		addDNSRecord(dnsRecords, fmt.Sprintf("_%s._%s.%s", portName, proto, parentSvc.ClusterSetFQDN()),
+			policy.DNSRecord{Type: policy.DNSRecordTypeSRV, Target: meshSvc.ClusterSetFQDN(), Port: meshSvc.Port})
+	}
+}
+
+// addDNSRecord adds the record to the name unless it is already present,
+// names are case-insensitive and recorded in lower case
+func addDNSRecord(dnsRecords map[string][]policy.DNSRecord, name string, record policy.DNSRecord) {
+	name = strings.ToLower(name)
+	record.Target = strings.ToLower(record.Target)
+	for _, existing := range dnsRecords[name] {
+		if existing == record {
+			return
+		}
+	}
+	dnsRecords[name] = append(dnsRecords[name], record)
+}
+
+// getServicePortName returns the name of the service port the MeshService corresponds to, if any
+func (mc *MeshCatalog) getServicePortName(meshSvc service.MeshService) string {
+	var svc *corev1.Service
+	if meshSvc.IsMultiClusterService() {
+		svc = mc.multiclusterController.GetService(meshSvc)
+	} else {
+		svc = mc.kubeController.GetService(meshSvc)
+	}
+	if svc == nil {
+		return ""
+	}
+
+	for _, port := range svc.Spec.Ports {
+		if uint16(port.Port) == meshSvc.Port {
+			return port.Name
+		}
+	}
+	return ""
+}
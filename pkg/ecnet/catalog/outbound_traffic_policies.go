@@ -1,7 +1,9 @@
 package catalog
 
 import (
-	mapset "github.com/deckarep/golang-set"
+	"fmt"
+	"net"
+	"strings"
 
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/errcode"
@@ -27,16 +29,17 @@ func (mc *MeshCatalog) GetOutboundMeshTrafficPolicy() *policy.OutboundMeshTraffi
 	var trafficMatches []*policy.TrafficMatch
 	var clusterConfigs []*policy.MeshClusterConfig
 	routeConfigPerPort := make(map[int][]*policy.OutboundTrafficPolicy)
-	servicesResolvableSet := make(map[string][]interface{})
+	servicesResolvableSet := make(map[string][]policy.DNSRecord)
 
 	// For each service, build the traffic policies required to access it.
 	// It is important to aggregate HTTP route configs by the service's port.
-	for _, meshSvc := range mc.ListOutboundServices() {
+	outboundServices := mc.ListOutboundServices()
+	headlessEndpointIPRanges := mc.getHeadlessEndpointIPRanges(outboundServices)
+	for _, meshSvc := range outboundServices {
 		meshSvc := meshSvc // To prevent loop variable memory aliasing in for loop
 		existMcsEndpoints := false
 
 		// Retrieve the destination IP address from the endpoints for this service
-		// IP range must not have duplicates, use a mapset to only add unique IP ranges
 		endpoints := mc.getDNSResolvableServiceEndpoints(meshSvc)
 		for _, endp := range endpoints {
 			if !existMcsEndpoints {
@@ -48,12 +51,22 @@ func (mc *MeshCatalog) GetOutboundMeshTrafficPolicy() *policy.OutboundMeshTraffi
 		}
 
 		if existMcsEndpoints {
-			resolvableIPSet := mapset.NewSet()
-			for _, endp := range endpoints {
-				resolvableIPSet.Add(endp.IP.String())
+			mc.addDNSRecords(servicesResolvableSet, meshSvc, endpoints)
+		}
+
+		// The TCP connections to an endpoint of a headless import can only be told apart by the addresses
+		// its hostname resolves to, the other protocols by the HTTP host header or the TLS server name
+		var endpointIPRanges []string
+		if meshSvc.IsMultiClusterService() && meshSvc.Subdomain() != "" &&
+			meshSvc.Protocol != constants.ProtocolHTTP && meshSvc.Protocol != constants.ProtocolGRPC &&
+			meshSvc.Protocol != constants.ProtocolHTTPS {
+			if meshSvc.Protocol != constants.ProtocolTCP && meshSvc.Protocol != constants.ProtocolTCPServerFirst {
+				continue
 			}
-			if resolvableIPSet.Cardinality() > 0 {
-				servicesResolvableSet[meshSvc.FQDN()] = resolvableIPSet.ToSlice()
+			endpointIPRanges = headlessEndpointIPRanges[meshSvc]
+			if len(endpointIPRanges) == 0 {
+				log.Debug().Msgf("No address of headless import endpoint %s can be told apart, skipping it", meshSvc)
+				continue
 			}
 		}
 
@@ -83,12 +96,24 @@ func (mc *MeshCatalog) GetOutboundMeshTrafficPolicy() *policy.OutboundMeshTraffi
 				DestinationProtocol: meshSvc.Protocol,
 				WeightedClusters:    routeMatch.UpstreamClusters,
 			}
+			// Connections to an import can be told apart by the virtual IP allocated to it,
+			// and to an endpoint of a headless import by the addresses of its hostname
+			if len(endpointIPRanges) > 0 {
+				trafficMatchForServicePort.DestinationIPRanges = endpointIPRanges
+			} else if meshSvc.IsMultiClusterService() {
+				trafficMatchForServicePort.DestinationIPRanges = mc.getClusterSetIPRanges(meshSvc)
+			}
+			// TLS connections to services sharing a port are routed by SNI
+			if strings.EqualFold(meshSvc.Protocol, constants.ProtocolHTTPS) {
+				trafficMatchForServicePort.ServerNames = k8s.GetServerNamesForService(meshSvc)
+			}
 			trafficMatches = append(trafficMatches, trafficMatchForServicePort)
 		}
 
 		// Build the HTTP route configs for this service and port combination.
-		// If the port's protocol corresponds to TCP, we can skip this step
-		if meshSvc.Protocol == constants.ProtocolTCP || meshSvc.Protocol == constants.ProtocolTCPServerFirst {
+		// If the port's protocol corresponds to TCP or UDP, we can skip this step
+		if meshSvc.Protocol == constants.ProtocolTCP || meshSvc.Protocol == constants.ProtocolTCPServerFirst ||
+			meshSvc.Protocol == constants.ProtocolUDP {
 			continue
 		}
 
@@ -117,6 +142,31 @@ func (mc *MeshCatalog) GetOutboundMeshTrafficPolicy() *policy.OutboundMeshTraffi
 				continue
 			}
 		}
+
+		// Apply the rate limiting of the imported or exported service, the route overrides are routed as the wildcard route
+		if rateLimit := mc.multiclusterController.GetRateLimitForService(meshSvc); rateLimit != nil {
+			outboundTrafficPolicy.RateLimit = rateLimit
+			upstreamClusters := mc.getWildCardRouteUpstreamClusters(hasTrafficSplitWildCard, routeMatches)
+			for _, routeRateLimit := range rateLimit.Routes {
+				if err := outboundTrafficPolicy.AddRateLimitedRoute(routeRateLimit, upstreamClusters...); err != nil {
+					log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrAddingRouteToOutboundTrafficPolicy)).
+						Msgf("Error adding rate limited route to outbound mesh HTTP traffic policy for destination %s", meshSvc)
+				}
+			}
+		}
+
+		// Apply the gRPC routing of the imported gRPC service, the gRPC routes are routed as the wildcard route
+		if meshSvc.IsMultiClusterService() && strings.EqualFold(meshSvc.Protocol, constants.ProtocolGRPC) {
+			if grpcRoutes := mc.multiclusterController.GetGRPCRoutesForService(meshSvc); len(grpcRoutes) > 0 {
+				upstreamClusters := mc.getWildCardRouteUpstreamClusters(hasTrafficSplitWildCard, routeMatches)
+				for _, grpcRoute := range grpcRoutes {
+					if err := outboundTrafficPolicy.AddGRPCRoute(grpcRoute, upstreamClusters...); err != nil {
+						log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrAddingRouteToOutboundTrafficPolicy)).
+							Msgf("Error adding gRPC route to outbound mesh HTTP traffic policy for destination %s", meshSvc)
+					}
+				}
+			}
+		}
 		routeConfigPerPort[int(meshSvc.Port)] = append(routeConfigPerPort[int(meshSvc.Port)], outboundTrafficPolicy)
 	}
 
@@ -128,6 +178,58 @@ func (mc *MeshCatalog) GetOutboundMeshTrafficPolicy() *policy.OutboundMeshTraffi
 	}
 }
 
+// getClusterSetIPRanges returns the ranges of the virtual IPs allocated to the imported service
+func (mc *MeshCatalog) getClusterSetIPRanges(meshSvc service.MeshService) []string {
+	_, vipNet, err := net.ParseCIDR(mc.configurator.GetClusterSetVIPCIDR())
+	if err != nil {
+		return nil
+	}
+	var ipRanges []string
+	for _, ip := range mc.multiclusterController.GetClusterSetIPsForService(meshSvc) {
+		if vipNet.Contains(net.ParseIP(ip)) {
+			ipRanges = append(ipRanges, ip+"/32")
+		}
+	}
+	return ipRanges
+}
+
+// getHeadlessEndpointIPRanges returns the ranges of the addresses the hostnames of the TCP endpoints of the headless imports
+// resolve to, keyed on the MeshServices of the endpoints. The addresses shared by several endpoints on a port are left out.
+func (mc *MeshCatalog) getHeadlessEndpointIPRanges(meshServices []service.MeshService) map[service.MeshService][]string {
+	ipRanges := make(map[service.MeshService][]string)
+	owners := make(map[string]map[service.MeshService]bool)
+	for _, meshSvc := range meshServices {
+		if !meshSvc.IsMultiClusterService() || meshSvc.Subdomain() == "" ||
+			(meshSvc.Protocol != constants.ProtocolTCP && meshSvc.Protocol != constants.ProtocolTCPServerFirst) {
+			continue
+		}
+		for _, endp := range mc.getDNSResolvableServiceEndpoints(meshSvc) {
+			ipRange := endp.IP.String() + "/32"
+			if endp.IP.To4() == nil {
+				ipRange = endp.IP.String() + "/128"
+			}
+			key := fmt.Sprintf("%s:%d", ipRange, meshSvc.Port)
+			if owners[key] == nil {
+				owners[key] = make(map[service.MeshService]bool)
+			}
+			if !owners[key][meshSvc] {
+				owners[key][meshSvc] = true
+				ipRanges[meshSvc] = append(ipRanges[meshSvc], ipRange)
+			}
+		}
+	}
+	for meshSvc, ranges := range ipRanges {
+		var unshared []string
+		for _, ipRange := range ranges {
+			if len(owners[fmt.Sprintf("%s:%d", ipRange, meshSvc.Port)]) == 1 {
+				unshared = append(unshared, ipRange)
+			}
+		}
+		ipRanges[meshSvc] = unshared
+	}
+	return ipRanges
+}
+
 func (mc *MeshCatalog) getWildCardRouteUpstreamClusters(hasTrafficSplitWildCard bool, routeMatches []*policy.HTTPRouteMatchWithWeightedClusters) []service.WeightedCluster {
 	var upstreamClusters []service.WeightedCluster
 	upstreamClusterMap := make(map[service.ClusterName]bool)
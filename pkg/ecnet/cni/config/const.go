@@ -9,9 +9,27 @@ const (
 	CNICreatePodURL = "/v1/cni/create-pod"
 	// CNIDeletePodURL is the route for cni plugin for deleting pod
 	CNIDeletePodURL = "/v1/cni/delete-pod"
+	// CNICheckPodURL is the route for cni plugin for checking pod
+	CNICheckPodURL = "/v1/cni/check-pod"
+	// CNIPodsStatusURL is the route for the attachment status of the pods
+	CNIPodsStatusURL = "/v1/cni/pods"
 
+	// ECNetEbpfMapPinPath is the directory the ebpf maps are pinned in
+	ECNetEbpfMapPinPath = "/sys/fs/bpf/tc/globals"
 	// ECNetDNSNatEbpfMap is the mount point of ecnet_dns_nat map
 	ECNetDNSNatEbpfMap = "/sys/fs/bpf/tc/globals/ecnet_dns_nat"
 	// ECNetSVCNatEbpfMap is the mount point of ecnet_svc_nat map
 	ECNetSVCNatEbpfMap = "/sys/fs/bpf/tc/globals/ecnet_svc_nat"
+	// ECNetUDPNatEbpfMap is the mount point of ecnet_udp_nat map
+	ECNetUDPNatEbpfMap = "/sys/fs/bpf/tc/globals/ecnet_udp_nat"
+	// ECNetUDPFlowEbpfMap is the mount point of ecnet_udp_flow map
+	ECNetUDPFlowEbpfMap = "/sys/fs/bpf/tc/globals/ecnet_udp_flow"
+	// ECNetConfigEbpfMap is the mount point of ecnet_config map
+	ECNetConfigEbpfMap = "/sys/fs/bpf/tc/globals/ecnet_config"
+	// ECNetStatsEbpfMap is the mount point of ecnet_stats map
+	ECNetStatsEbpfMap = "/sys/fs/bpf/tc/globals/ecnet_stats"
+	// ECNetGetSockoptEbpfProg is the mount point of get_sockopt prog
+	ECNetGetSockoptEbpfProg = "/sys/fs/bpf/get_sockopts"
+	// ECNetGetSockoptEbpfLink is the mount point of the link attaching get_sockopt prog to cgroup2
+	ECNetGetSockoptEbpfLink = "/sys/fs/bpf/get_sockopts_link"
 )
@@ -1,8 +1,8 @@
 package config
 
+import "time"
+
 var (
-	// KernelTracing indicates debug feature of/off
-	KernelTracing = false
 	// IsKind indicates Kubernetes running in Docker
 	IsKind = false
 	// BridgeEth indicates cni bridge dev
@@ -15,4 +15,22 @@ var (
 	CNIConfigDir string
 	// HostVarRun defines HostVar volume
 	HostVarRun string
+	// ExcludeNamespaces defines the namespaces whose pods are never intercepted
+	ExcludeNamespaces []string
+	// InterceptByDefault defines whether the pods are intercepted when neither they nor their namespace are annotated
+	InterceptByDefault = true
+	// CRIEndpoint defines the CRI socket of the container runtime, detected under HostVarRun when empty
+	CRIEndpoint string
+	// UDPNatIdleTimeout defines how long the original destination of an idle udp flow is kept
+	UDPNatIdleTimeout time.Duration
+	// TCPNatIdleTimeout defines how long the original destination of an idle tcp connection is kept
+	TCPNatIdleTimeout time.Duration
+	// SvcNatMapSize defines the max entries of the ecnet_svc_nat map
+	SvcNatMapSize uint32
+	// DNSNatMapSize defines the max entries of the ecnet_dns_nat map
+	DNSNatMapSize uint32
+	// BPFObjectsDir defines the directory of the precompiled ebpf objects
+	BPFObjectsDir string
+	// CGroup2Path defines the cgroup2 mount the getsockopt prog is attached to, detected when empty
+	CGroup2Path string
 )
@@ -51,3 +51,41 @@ func (s *server) PodDeleted(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("ok"))
 }
+
+func (s *server) PodChecked(w http.ResponseWriter, req *http.Request) {
+	bs, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	args := skel.CmdArgs{}
+	if err = json.Unmarshal(bs, &args); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	log.Debug().Msgf("cni called check with args: %+v", args)
+	if err = s.CmdCheck(&args); err != nil {
+		log.Warn().Msgf("cni check failed: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// PodsStatusHandler serves the attachment status of the pods of the node
+func PodsStatusHandler(s Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		bs, err := json.Marshal(s.PodsStatus())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bs)
+	})
+}
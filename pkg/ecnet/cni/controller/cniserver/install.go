@@ -3,14 +3,16 @@ package cniserver
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/containernetworking/cni/libcni"
 	"github.com/pkg/errors"
@@ -24,6 +26,10 @@ const (
 	ecnetCniName       = "ecnet-cni"
 	kubeConfigFileName = "ZZZ-ecnet-cni-kubeconfig"
 	tokenPath          = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	caPath             = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	// tokenRefreshInterval is how often the projected service account token is checked for rotation
+	tokenRefreshInterval = time.Minute
 
 	kubeConfigTemplate = `# Kubeconfig file for ECNET CNI plugin.
 apiVersion: v1
@@ -67,6 +73,7 @@ func newInstaller() *installer {
 // Run starts the installation process, verifies the configuration, then sleeps.
 // If an invalid configuration is detected, the installation process will restart to restore a valid state.
 func (in *installer) Run(ctx context.Context, cniReady chan struct{}) error {
+	go in.refreshKubeconfig(ctx)
 	for {
 		if err := copyBinaries(); err != nil {
 			return err
@@ -148,17 +155,50 @@ func (in *installer) Cleanup() error {
 	return nil
 }
 
-func createCNIConfigFile(ctx context.Context) (string, error) {
-	// TODO(dddddai): support ExcludeNamespaces?
-	cniConfig := fmt.Sprintf(`
-	{
-		"type": "ecnet-cni",
-		"kubernetes": {
-			"kubeconfig": "/etc/cni/net.d/%s"
+// refreshKubeconfig rewrites the kubeconfig file when the projected service account token is rotated
+func (in *installer) refreshKubeconfig(ctx context.Context) {
+	lastToken, _ := readServiceAccountToken()
+	ticker := time.NewTicker(tokenRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		saToken, err := readServiceAccountToken()
+		if err != nil {
+			log.Error().Msgf("Failed to read service account token: %v", err)
+			continue
+		}
+		// the kubeconfig file is not written back once removed by Cleanup
+		if saToken == lastToken || len(in.kubeConfigFilepath) == 0 || !util.Exists(in.kubeConfigFilepath) {
+			continue
 		}
-	}`, kubeConfigFileName)
+		log.Info().Msg("Service account token rotated, refreshing the CNI kubeconfig")
+		if _, err = createKubeconfigFile(saToken); err != nil {
+			log.Error().Msgf("Failed to refresh the CNI kubeconfig: %v", err)
+			continue
+		}
+		lastToken = saToken
+	}
+}
+
+func createCNIConfigFile(ctx context.Context) (string, error) {
+	cniConfig, err := json.Marshal(map[string]interface{}{
+		"type": ecnetCniName,
+		"kubernetes": map[string]interface{}{
+			"kubeconfig": path.Join("/etc/cni/net.d", kubeConfigFileName),
+		},
+		// the pods of these namespaces are ignored by the plugin
+		"exclude_namespaces": config.ExcludeNamespaces,
+	})
+	if err != nil {
+		return "", err
+	}
 
-	return writeCNIConfig(ctx, []byte(cniConfig))
+	return writeCNIConfig(ctx, cniConfig)
 }
 
 func insertCNIConfig(cniConfig, existingCNIConfig []byte) ([]byte, error) {
@@ -195,13 +235,14 @@ func insertCNIConfig(cniConfig, existingCNIConfig []byte) ([]byte, error) {
 			return nil, fmt.Errorf("existing CNI config: %v", err)
 		}
 
-		for _, rawPlugin := range plugins {
+		for i, rawPlugin := range plugins {
 			plugin, err := util.GetPlugin(rawPlugin)
 			if err != nil {
 				return nil, fmt.Errorf("existing CNI plugin: %v", err)
 			}
 			if plugin["type"] == ecnetCniName {
-				// it already contains ecnet-cni
+				// it already contains ecnet-cni, which may have been written with other settings
+				plugins[i] = pluginMap
 				return util.MarshalCNIConfig(newMap)
 			}
 		}
@@ -405,8 +446,12 @@ func createKubeconfigFile(saToken string) (kubeconfigFilepath string, err error)
 		protocol = "https"
 	}
 
-	// TODO: support tls
 	tlsConfig := "insecure-skip-tls-verify: true"
+	if ca, caErr := os.ReadFile(caPath); caErr == nil {
+		tlsConfig = fmt.Sprintf("certificate-authority-data: %s", base64.StdEncoding.EncodeToString(ca))
+	} else {
+		log.Warn().Msgf("Failed to read service account CA %s, skipping TLS verification: %v", caPath, caErr)
+	}
 
 	fields := kubeconfigFields{
 		KubernetesServiceProtocol: protocol,
@@ -1,40 +1,25 @@
 package cniserver
 
 import (
-	"bufio"
-	"bytes"
-	"errors"
 	"fmt"
-	"hash/fnv"
 	"net"
-	"os"
-	"path"
 	"runtime/debug"
-	"strconv"
-	"strings"
-	"syscall"
 
-	"github.com/cilium/ebpf"
 	"github.com/containernetworking/cni/pkg/skel"
-	"github.com/containernetworking/cni/pkg/types"
+	cnitypes "github.com/containernetworking/cni/pkg/types"
 	"github.com/florianl/go-tc"
 	"github.com/florianl/go-tc/core"
 	"golang.org/x/sys/unix"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
-	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/helpers"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/ns"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/plugin"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/util"
 )
 
-func getMarkKeyOfNetns(netns string) uint32 {
-	// todo check conflict?
-	algorithm := fnv.New32a()
-	_, _ = algorithm.Write([]byte(netns))
-	return algorithm.Sum32()
-}
-
 func (s *server) CmdAdd(args *skel.CmdArgs) (err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -51,28 +36,43 @@ func (s *server) CmdAdd(args *skel.CmdArgs) (err error) {
 		}
 	}()
 	k8sArgs := plugin.K8sArgs{}
-	if err := types.LoadArgs(args.Args, &k8sArgs); err != nil {
+	if err := cnitypes.LoadArgs(args.Args, &k8sArgs); err != nil {
 		return err
 	}
+	pod, err := s.interception.GetPod(string(k8sArgs.K8S_POD_NAMESPACE), string(k8sArgs.K8S_POD_NAME))
+	if err != nil {
+		// The sandbox must start even when the api server is unreachable, the interception is decided from
+		// the namespace of the pod, and fixed up by the reconciliation once the pod is in the cache
+		log.Warn().Msgf("get pod %s/%s error, deciding its interception from its namespace: %v", k8sArgs.K8S_POD_NAMESPACE, k8sArgs.K8S_POD_NAME, err)
+		pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: string(k8sArgs.K8S_POD_NAMESPACE),
+			Name:      string(k8sArgs.K8S_POD_NAME),
+			UID:       types.UID(k8sArgs.K8S_POD_UID),
+		}}
+		err = nil
+	}
+	if len(args.IfName) != 0 {
+		s.setPodIfName(pod.UID, args.IfName)
+	}
+	if !s.interception.IsIntercepted(pod) {
+		log.Debug().Msgf("skip intercepting pod %s/%s", pod.Namespace, pod.Name)
+		return nil
+	}
+
 	netns, err := ns.GetNS("/host" + args.Netns)
 	if err != nil {
 		log.Error().Msgf("get ns %s error", args.Netns)
 		return err
 	}
+	s.setPodNetns(pod.UID, netns.Path())
 
 	err = netns.Do(func(_ ns.NetNS) error {
 		// attach tc to the device
 		if len(args.IfName) != 0 {
-			return s.attachTC(netns.Path(), args.IfName)
+			return s.attachTC(pod.UID, netns.Path(), args.IfName)
 		}
 		// interface not specified, should not happen?
-		ifaces, _ := net.Interfaces()
-		for _, iface := range ifaces {
-			if (iface.Flags&net.FlagLoopback) == 0 && (iface.Flags&net.FlagUp) != 0 {
-				return s.attachTC(netns.Path(), iface.Name)
-			}
-		}
-		return fmt.Errorf("device not found for %s", args.Netns)
+		return s.attachTCToDefaultDevice(pod.UID, netns.Path())
 	})
 	if err != nil {
 		log.Error().Msgf("CmdAdd failed for %s: %v", args.Netns, err)
@@ -81,9 +81,32 @@ func (s *server) CmdAdd(args *skel.CmdArgs) (err error) {
 	return err
 }
 
+// CmdCheck returns an error when the pod is intercepted but the clsact qdisc or any of the tc filters is missing
+func (s *server) CmdCheck(args *skel.CmdArgs) error {
+	k8sArgs := plugin.K8sArgs{}
+	if err := cnitypes.LoadArgs(args.Args, &k8sArgs); err != nil {
+		return err
+	}
+	pod, err := s.interception.GetPod(string(k8sArgs.K8S_POD_NAMESPACE), string(k8sArgs.K8S_POD_NAME))
+	if err != nil {
+		// Like CmdAdd, the pod is not failed for an unreachable api server
+		log.Warn().Msgf("get pod %s/%s error, skipping the check: %v", k8sArgs.K8S_POD_NAMESPACE, k8sArgs.K8S_POD_NAME, err)
+		return nil
+	}
+	if !s.interception.IsIntercepted(pod) {
+		return nil
+	}
+
+	st := PodStatus{Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID, Intercepted: true}
+	if err = getAttachmentStatus(&st, "/host"+args.Netns, args.IfName); err != nil {
+		return fmt.Errorf("check pod %s/%s error: %v", pod.Namespace, pod.Name, err)
+	}
+	return checkAttachment(&st)
+}
+
 func (s *server) CmdDelete(args *skel.CmdArgs) (err error) {
 	k8sArgs := plugin.K8sArgs{}
-	if err := types.LoadArgs(args.Args, &k8sArgs); err != nil {
+	if err := cnitypes.LoadArgs(args.Args, &k8sArgs); err != nil {
 		return err
 	}
 	netns := "/host" + args.Netns
@@ -92,90 +115,14 @@ func (s *server) CmdDelete(args *skel.CmdArgs) (err error) {
 		return err
 	}
 	s.Lock()
+	defer s.Unlock()
 
 	delete(s.qdiscs, inode)
 	delete(s.listeners, inode)
-
-	s.Unlock()
-	m, err := ebpf.LoadPinnedMap(path.Join(s.bpfMountPath, "ecnet_mark_fib"), &ebpf.LoadPinOptions{})
-	if err != nil {
-		return err
-	}
-	key := getMarkKeyOfNetns(args.Netns)
-	return m.Delete(key)
-}
-
-func (s *server) checkAndRepairPodPrograms() error {
-	hostProc, err := os.ReadDir(config.HostProc)
-	if err != nil {
-		return err
-	}
-	for _, f := range hostProc {
-		if _, err = strconv.Atoi(f.Name()); err == nil {
-			pid := f.Name()
-			if skipListening(pid) {
-				// ignore non-injected pods
-				log.Debug().Msgf("skip listening for pid(%s)", pid)
-				continue
-			}
-			np := fmt.Sprintf("%s/%s/ns/net", config.HostProc, pid)
-			netns, err := ns.GetNS(np)
-			if err != nil {
-				log.Error().Msgf("Failed to get ns for %s, error: %v", np, err)
-				continue
-			}
-			if err = netns.Do(func(_ ns.NetNS) error {
-				// attach tc to the device
-				ifaces, _ := net.Interfaces()
-				for _, iface := range ifaces {
-					if (iface.Flags&net.FlagLoopback) == 0 && (iface.Flags&net.FlagUp) != 0 {
-						err := s.attachTC(netns.Path(), iface.Name)
-						if err != nil {
-							log.Error().Msgf("attach tc for %s of %s error: %v", iface.Name, netns.Path(), err)
-						}
-						return err
-					}
-				}
-				return fmt.Errorf("device not found for pid(%s)", pid)
-			}); err != nil {
-				if errors.Is(err, syscall.EADDRINUSE) {
-					// skip if it has listened on 15050
-					continue
-				}
-				return err
-			}
-		}
-	}
+	// the nat entries of the pod are evicted by the sweeper once it is gone
 	return nil
 }
 
-func skipListening(pid string) bool {
-	b, _ := os.ReadFile(fmt.Sprintf("%s/%s/comm", config.HostProc, pid))
-	comm := strings.TrimSpace(string(b))
-
-	if comm != "pilot-agent" {
-		return true
-	}
-
-	findStr := func(path string, str []byte) bool {
-		f, _ := os.Open(path)
-		defer func(f *os.File) {
-			_ = f.Close()
-		}(f)
-		sc := bufio.NewScanner(f)
-		sc.Split(bufio.ScanLines)
-		for sc.Scan() {
-			if bytes.Contains(sc.Bytes(), str) {
-				return true
-			}
-		}
-		return false
-	}
-
-	conn4 := fmt.Sprintf("%s/%s/net/tcp", config.HostProc, pid)
-	return !findStr(conn4, []byte(fmt.Sprintf(": %0.8d:%0.4X %0.8d:%0.4X 0A", 0, 15001, 0, 0)))
-}
-
 func uint32Ptr(v uint32) *uint32 {
 	return &v
 }
@@ -184,12 +131,39 @@ func stringPtr(v string) *string {
 	return &v
 }
 
-func (s *server) attachTC(netns, dev string) error {
+// attachTCToDefaultDevice attaches the tc programs to the first device of the netns it runs in
+func (s *server) attachTCToDefaultDevice(pod types.UID, netns string) error {
+	iface, err := getDefaultDevice()
+	if err != nil {
+		return fmt.Errorf("%v for %s", err, netns)
+	}
+	return s.attachTC(pod, netns, iface.Name)
+}
+
+// getDefaultDevice returns the first device of the netns it runs in
+func getDefaultDevice() (*net.Interface, error) {
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if (iface.Flags&net.FlagLoopback) == 0 && (iface.Flags&net.FlagUp) != 0 {
+			iface := iface
+			return &iface, nil
+		}
+	}
+	return nil, fmt.Errorf("device not found")
+}
+
+func (s *server) attachTC(pod types.UID, netns, dev string) error {
 	// already in netns
 	inode, err := util.Inode(netns)
 	if err != nil {
 		return err
 	}
+	s.Lock()
+	_, attached := s.qdiscs[inode]
+	s.Unlock()
+	if attached {
+		return nil
+	}
 	iface, err := net.InterfaceByName(dev)
 	if err != nil {
 		log.Error().Msgf("get iface error: %v", err)
@@ -296,6 +270,7 @@ func (s *server) attachTC(netns, dev string) error {
 		netns:         netns,
 		device:        dev,
 		managedClsact: !find,
+		pod:           pod,
 	}
 	s.Unlock()
 	return nil
@@ -305,70 +280,111 @@ func (s *server) cleanUpTC() {
 	s.Lock()
 	defer s.Unlock()
 	for _, q := range s.qdiscs {
-		netns, err := ns.GetNS(q.netns)
+		if err := removeTC(q); err != nil {
+			log.Error().Msgf("Failed to clean up tc for %s, error: %v", q.netns, err)
+		}
+	}
+}
+
+// detachTC removes the tc programs attached to the netns, if any
+func (s *server) detachTC(netns string) error {
+	inode, err := util.Inode(netns)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	q, attached := s.qdiscs[inode]
+	delete(s.qdiscs, inode)
+	s.Unlock()
+	if !attached {
+		return nil
+	}
+	// the process the qdisc was attached through may be gone
+	q.netns = netns
+	return removeTC(q)
+}
+
+// removeTC removes the clsact qdisc when it was added by the server, the tc filters otherwise
+func removeTC(q qdisc) error {
+	netns, err := ns.GetNS(q.netns)
+	if err != nil {
+		return err
+	}
+	return netns.Do(func(_ ns.NetNS) error {
+		return removeTCFromDevice(q)
+	})
+}
+
+// removeTCFromDevice does the job of removeTC, in the netns it runs in
+func removeTCFromDevice(q qdisc) error {
+	iface, err := net.InterfaceByName(q.device)
+	if err != nil {
+		return err
+	}
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			log.Error().Msgf("could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+	if q.managedClsact {
+		err := rtnl.Qdisc().Delete(&tc.Object{
+			Msg: tc.Msg{
+				Family:  unix.AF_UNSPEC,
+				Ifindex: uint32(iface.Index),
+				Handle:  core.BuildHandle(0xFFFF, 0x0000),
+				Parent:  tc.HandleIngress,
+			},
+			Attribute: tc.Attribute{
+				Kind: "clsact",
+			},
+		})
 		if err != nil {
-			log.Error().Msgf("Failed to get ns for %s, error: %v", q.netns, err)
-			continue
+			log.Error().Msgf("error remove clsact: ns: %s, dev: %s, err: %v", q.netns, q.device, err)
+			// if remove clsact error, rollback to remove filter
+		} else {
+			return nil
 		}
-		if err = netns.Do(func(_ ns.NetNS) error {
-			iface, err := net.InterfaceByName(q.device)
-			if err != nil {
-				return err
-			}
-			rtnl, err := tc.Open(&tc.Config{})
-			if err != nil {
-				return err
-			}
-			defer func() {
-				if err := rtnl.Close(); err != nil {
-					log.Error().Msgf("could not close rtnetlink socket: %v\n", err)
-				}
-			}()
-			if q.managedClsact {
-				err := rtnl.Qdisc().Delete(&tc.Object{
-					Msg: tc.Msg{
-						Family:  unix.AF_UNSPEC,
-						Ifindex: uint32(iface.Index),
-						Handle:  core.BuildHandle(0xFFFF, 0x0000),
-						Parent:  tc.HandleIngress,
-					},
-					Attribute: tc.Attribute{
-						Kind: "clsact",
-					},
-				})
-				if err != nil {
-					log.Error().Msgf("error remove clsact: ns: %s, dev: %s, err: %v", q.netns, q.device, err)
-					// if remove clsact error, rollback to remove filter
-				} else {
-					return nil
-				}
-			}
-			filter := tc.Object{
-				Msg: tc.Msg{
-					Family:  unix.AF_UNSPEC,
-					Ifindex: uint32(iface.Index),
-					Parent:  0xFFFFFFF2,
-					Info: core.BuildHandle(
-						66,     // prio
-						0x0300, // protocol
-					),
-				},
-			}
-			_ = rtnl.Filter().Delete(&filter)
-			filter = tc.Object{
-				Msg: tc.Msg{
-					Family:  unix.AF_UNSPEC,
-					Ifindex: uint32(iface.Index),
-					Parent:  0xFFFFFFF3,
-					Info: core.BuildHandle(
-						66,     // prio
-						0x0300, // protocol
-					),
-				},
-			}
-			return rtnl.Filter().Delete(&filter)
-		}); err != nil {
-			log.Error().Msgf("Failed to clean up tc for %s, error: %v", q.netns, err)
+	}
+	return removeTCFilters(iface.Index)
+}
+
+// removeTCFilters removes the ingress and egress filters from the device of the netns it runs in
+func removeTCFilters(ifindex int) error {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			log.Error().Msgf("could not close rtnetlink socket: %v\n", err)
 		}
+	}()
+	filter := tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: uint32(ifindex),
+			Parent:  0xFFFFFFF2,
+			Info: core.BuildHandle(
+				66,     // prio
+				0x0300, // protocol
+			),
+		},
+	}
+	_ = rtnl.Filter().Delete(&filter)
+	filter = tc.Object{
+		Msg: tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: uint32(ifindex),
+			Parent:  0xFFFFFFF3,
+			Info: core.BuildHandle(
+				66,     // prio
+				0x0300, // protocol
+			),
+		},
 	}
+	return rtnl.Filter().Delete(&filter)
 }
@@ -0,0 +1,344 @@
+package cniserver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/florianl/go-tc"
+	"golang.org/x/sys/unix"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/helpers"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/ns"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/util"
+)
+
+const (
+	tcFilterNamePrefix = "ecnet_cni_tc_"
+
+	// defaultPodIfName is the name kubelet gives to the interface of the pods at CNI ADD
+	defaultPodIfName = "eth0"
+)
+
+var (
+	podUIDRegex = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+// SyncPod compares the tc filters of a running pod with the desired state, and repairs the drift:
+// the filters are attached when the traffic of the pod is intercepted, and detached otherwise.
+func (s *server) SyncPod(pod *corev1.Pod) error {
+	intercepted := s.interception.IsIntercepted(pod)
+	np, err := s.getPodNetns(pod.UID)
+	if err != nil {
+		if !intercepted {
+			return nil
+		}
+		return err
+	}
+	inode, err := util.Inode(np)
+	if err != nil {
+		return err
+	}
+	netns, err := ns.GetNS(np)
+	if err != nil {
+		return err
+	}
+	defer netns.Close() // nolint: errcheck
+
+	s.Lock()
+	q, tracked := s.qdiscs[inode]
+	ifName, recorded := s.podIfNames[pod.UID]
+	s.Unlock()
+	switch {
+	case recorded:
+	case tracked:
+		ifName = q.device
+	default:
+		// The CNI ADD of the pod was served by a previous instance, the interface is the one named by kubelet
+		ifName = defaultPodIfName
+	}
+
+	return netns.Do(func(_ ns.NetNS) error {
+		iface, err := net.InterfaceByName(ifName)
+		if err != nil {
+			return fmt.Errorf("get device %s of pod %s/%s: %v", ifName, pod.Namespace, pod.Name, err)
+		}
+		ingress, egress, err := getTCFilters(iface.Index)
+		if err != nil {
+			return err
+		}
+
+		if !tracked {
+			q = qdisc{netns: np, device: iface.Name, pod: pod.UID}
+		}
+		q.netns = np
+
+		switch {
+		case intercepted && ingress && egress:
+			if !tracked {
+				// attached by a previous instance, adopt the filters with the programs of this instance
+				if err := replaceTCProgs(iface.Index); err != nil {
+					return fmt.Errorf("replace tc programs of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				}
+				s.trackQdisc(inode, q)
+			}
+			return nil
+
+		case intercepted:
+			log.Info().Msgf("attach tc of pod %s/%s", pod.Namespace, pod.Name)
+			s.untrackQdisc(inode)
+			if ingress || egress {
+				// half attached, start over so that no filter is doubled
+				_ = removeTCFilters(iface.Index)
+			}
+			return s.attachTC(pod.UID, np, iface.Name)
+
+		case ingress || egress || tracked:
+			log.Info().Msgf("detach tc of pod %s/%s", pod.Namespace, pod.Name)
+			s.untrackQdisc(inode)
+			return removeTCFromDevice(q)
+		}
+		return nil
+	})
+}
+
+// ForgetPod drops the state kept for a deleted pod, its netns is gone along with the tc filters
+func (s *server) ForgetPod(uid types.UID) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.podNetns, uid)
+	delete(s.podIfNames, uid)
+	for inode, q := range s.qdiscs {
+		if q.pod == uid {
+			delete(s.qdiscs, inode)
+		}
+	}
+}
+
+func (s *server) trackQdisc(inode uint64, q qdisc) {
+	s.Lock()
+	defer s.Unlock()
+	s.qdiscs[inode] = q
+}
+
+func (s *server) untrackQdisc(inode uint64) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.qdiscs, inode)
+}
+
+func (s *server) setPodIfName(uid types.UID, ifName string) {
+	s.Lock()
+	defer s.Unlock()
+	s.podIfNames[uid] = ifName
+}
+
+func (s *server) setPodNetns(uid types.UID, netns string) {
+	s.Lock()
+	defer s.Unlock()
+	s.podNetns[uid] = netns
+}
+
+// getPodNetns returns the netns path of a running pod, the one given by the CNI plugin if it still
+// exists, else the one of its sandbox reported by the container runtime, else the one of the first of its processes
+func (s *server) getPodNetns(uid types.UID) (string, error) {
+	s.Lock()
+	np, ok := s.podNetns[uid]
+	s.Unlock()
+	if ok {
+		if _, err := os.Stat(np); err == nil {
+			return np, nil
+		}
+	}
+
+	if s.resolver != nil {
+		np, err := s.resolver.GetPodNetns(uid)
+		if err == nil {
+			if _, err = os.Stat(np); err == nil {
+				s.setPodNetns(uid, np)
+				return np, nil
+			}
+		}
+		log.Debug().Msgf("resolve netns of pod %s through the container runtime: %v", uid, err)
+	}
+
+	np, err := findPodNetns(uid)
+	if err != nil {
+		return "", err
+	}
+	s.setPodNetns(uid, np)
+	return np, nil
+}
+
+// findPodNetns looks up the netns path of a running pod through its processes
+func findPodNetns(uid types.UID) (string, error) {
+	hostProc, err := os.ReadDir(config.HostProc)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range hostProc {
+		if _, err = strconv.Atoi(f.Name()); err != nil {
+			continue
+		}
+		if podUID, ok := getPodUIDOfPid(f.Name()); ok && podUID == uid {
+			return fmt.Sprintf("%s/%s/ns/net", config.HostProc, f.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("no process found for pod %s", uid)
+}
+
+// getPodUIDOfPid returns the uid of the pod a process belongs to, from its cgroup path,
+// ie: /kubepods/burstable/pod<uid>/<container> or /kubepods.slice/kubepods-pod<uid with _>.slice/...
+func getPodUIDOfPid(pid string) (types.UID, bool) {
+	b, err := os.ReadFile(fmt.Sprintf("%s/%s/cgroup", config.HostProc, pid))
+	if err != nil {
+		return "", false
+	}
+	match := podUIDRegex.FindSubmatch(b)
+	if match == nil {
+		return "", false
+	}
+	return types.UID(strings.ReplaceAll(string(match[1]), "_", "-")), true
+}
+
+// getTCFilters returns whether the ingress and egress filters are attached to the device of the netns it runs in
+func getTCFilters(ifindex int) (ingress, egress bool, err error) {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		return false, false, err
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			log.Error().Msgf("could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+
+	hasFilter := func(parent uint32) (bool, error) {
+		filters, err := rtnl.Filter().Get(&tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: uint32(ifindex),
+			Parent:  parent,
+		})
+		if err != nil {
+			return false, err
+		}
+		for _, filter := range filters {
+			if isEcnetFilter(filter) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if ingress, err = hasFilter(0xFFFFFFF2); err != nil {
+		return false, false, err
+	}
+	if egress, err = hasFilter(0xFFFFFFF3); err != nil {
+		return false, false, err
+	}
+	return ingress, egress, nil
+}
+
+// hasClsactQdisc returns whether the clsact qdisc is set up on the device of the netns it runs in
+func hasClsactQdisc(ifindex int) (bool, error) {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			log.Error().Msgf("could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+
+	qdiscs, err := rtnl.Qdisc().Get()
+	if err != nil {
+		return false, err
+	}
+	for _, qdisc := range qdiscs {
+		if qdisc.Kind == "clsact" && qdisc.Ifindex == uint32(ifindex) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// replaceTCProgs atomically replaces the programs of the filters of the device of the netns it runs in,
+// so that the filters attached by a previous instance run the programs loaded by this instance
+func replaceTCProgs(ifindex int) error {
+	rtnl, err := tc.Open(&tc.Config{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rtnl.Close(); err != nil {
+			log.Error().Msgf("could not close rtnetlink socket: %v\n", err)
+		}
+	}()
+
+	for _, hook := range []struct {
+		parent uint32
+		prog   *ebpf.Program
+	}{
+		{parent: 0xFFFFFFF2, prog: helpers.GetTrafficControlIngressProg()},
+		{parent: 0xFFFFFFF3, prog: helpers.GetTrafficControlEgressProg()},
+	} {
+		if hook.prog == nil {
+			return fmt.Errorf("can not get tc prog")
+		}
+		var progID uint32
+		if info, err := hook.prog.Info(); err == nil {
+			if id, ok := info.ID(); ok {
+				progID = uint32(id)
+			}
+		}
+
+		filters, err := rtnl.Filter().Get(&tc.Msg{
+			Family:  unix.AF_UNSPEC,
+			Ifindex: uint32(ifindex),
+			Parent:  hook.parent,
+		})
+		if err != nil {
+			return err
+		}
+		for _, filter := range filters {
+			if !isEcnetFilter(filter) || (filter.BPF.ID != nil && *filter.BPF.ID == progID) {
+				continue
+			}
+			// the filter is matched by its handle, priority and protocol, and replaced in place
+			if err = rtnl.Filter().Replace(&tc.Object{
+				Msg: tc.Msg{
+					Family:  unix.AF_UNSPEC,
+					Ifindex: uint32(ifindex),
+					Handle:  filter.Handle,
+					Parent:  hook.parent,
+					Info:    filter.Info,
+				},
+				Attribute: tc.Attribute{
+					Kind: "bpf",
+					BPF: &tc.Bpf{
+						FD:    uint32Ptr(uint32(hook.prog.FD())),
+						Name:  filter.BPF.Name,
+						Flags: uint32Ptr(0x1),
+					},
+				},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isEcnetFilter returns whether the filter runs one of the tc programs of ecnet
+func isEcnetFilter(filter tc.Object) bool {
+	return filter.Kind == "bpf" && filter.BPF != nil && filter.BPF.Name != nil &&
+		strings.HasPrefix(*filter.BPF.Name, tcFilterNamePrefix)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
 )
@@ -19,6 +20,8 @@ type qdisc struct {
 	netns         string
 	device        string
 	managedClsact bool
+	// pod is the uid of the pod owning the netns, empty when unknown
+	pod types.UID
 }
 
 type server struct {
@@ -31,6 +34,18 @@ type server struct {
 	// listeners are the dummy sockets created for eBPF programs to fetch the current pod ip
 	// key: netns(inode), value: net.Listener
 	listeners map[uint64]net.Listener
+	// podNetns caches the netns path of the pods
+	// key: pod uid, value: netns path
+	podNetns map[types.UID]string
+	// podIfNames records the name of the interface of the pods given at CNI ADD
+	// key: pod uid, value: interface name
+	podIfNames map[types.UID]string
+	// interception decides which pods get the tc programs attached
+	interception Interception
+	// resolver resolves the netns of the pods through the container runtime, optional
+	resolver NetnsResolver
+	// installer installs the CNI plugin, which is only removed by CleanUp
+	installer *installer
 
 	cniReady chan struct{}
 	stop     chan struct{}
@@ -38,7 +53,7 @@ type server struct {
 
 // NewServer returns a new CNI Server.
 // the path this the unix path to listen.
-func NewServer(unixSockPath string, bpfMountPath string, cniReady, stop chan struct{}) Server {
+func NewServer(unixSockPath string, bpfMountPath string, interception Interception, resolver NetnsResolver, cniReady, stop chan struct{}) Server {
 	if unixSockPath == "" {
 		unixSockPath = config.CNISock
 	}
@@ -50,6 +65,10 @@ func NewServer(unixSockPath string, bpfMountPath string, cniReady, stop chan str
 		bpfMountPath: bpfMountPath,
 		qdiscs:       make(map[uint64]qdisc),
 		listeners:    make(map[uint64]net.Listener),
+		podNetns:     make(map[types.UID]string),
+		podIfNames:   make(map[types.UID]string),
+		interception: interception,
+		resolver:     resolver,
 		cniReady:     cniReady,
 		stop:         stop,
 	}
@@ -73,6 +92,14 @@ func (s *server) Start() error {
 		Methods("POST").
 		HandlerFunc(s.PodDeleted)
 
+	r.Path(config.CNICheckPodURL).
+		Methods("POST").
+		HandlerFunc(s.PodChecked)
+
+	r.Path(config.CNIPodsStatusURL).
+		Methods("GET").
+		Handler(PodsStatusHandler(s))
+
 	ss := http.Server{
 		Handler:      r,
 		WriteTimeout: 15 * time.Second,
@@ -80,51 +107,50 @@ func (s *server) Start() error {
 	}
 	go func() {
 		go ss.Serve(l) // nolint: errcheck
-		// TODO: unify all clean-up functions
 		ch := make(chan os.Signal, 1)
 		signal.Notify(ch, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGABRT)
 		select {
 		case <-ch:
 			s.Stop()
 		case <-s.stop:
-			s.Stop()
 		}
 		_ = ss.Shutdown(context.Background())
 	}()
 
 	s.installCNI()
-	// wait for cni to be ready
+	// wait for cni to be ready, the pods already running are then reconciled by the pod watcher
 	<-s.cniReady
-	if err = s.checkAndRepairPodPrograms(); err != nil {
-		log.Error().Msgf("Failed to check existing pods: %v", err)
-	}
 	return nil
 }
 
 func (s *server) installCNI() {
-	install := newInstaller()
+	s.installer = newInstaller()
 	go func() {
-		if err := install.Run(context.TODO(), s.cniReady); err != nil {
+		if err := s.installer.Run(context.TODO(), s.cniReady); err != nil {
 			log.Error().Err(err)
 			close(s.cniReady)
-		}
-		if err := install.Cleanup(); err != nil {
-			log.Error().Msgf("Failed to clean up CNI: %v", err)
-		}
-	}()
-
-	go func() {
-		ch := make(chan os.Signal, 1)
-		signal.Notify(ch, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGABRT)
-		<-ch
-		if err := install.Cleanup(); err != nil {
-			log.Error().Msgf("Failed to clean up CNI: %v", err)
+			if err = s.installer.Cleanup(); err != nil {
+				log.Error().Msgf("Failed to clean up CNI: %v", err)
+			}
 		}
 	}()
 }
 
+// Stop stops the server, leaving the CNI plugin installed and the tc programs attached to the pods,
+// so that the next instance takes them over without disrupting the traffic
 func (s *server) Stop() {
 	log.Info().Msg("cni-server stop ...")
-	s.cleanUpTC()
 	close(s.stop)
 }
+
+// CleanUp detaches the tc programs from the pods and uninstalls the CNI plugin
+func (s *server) CleanUp() {
+	log.Info().Msg("cni-server clean up ...")
+	s.cleanUpTC()
+	if s.installer == nil {
+		return
+	}
+	if err := s.installer.Cleanup(); err != nil {
+		log.Error().Msgf("Failed to clean up CNI: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package cniserver
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/helpers"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/ns"
+)
+
+// PodsStatus returns the attachment status of the running pods of the node, host network pods excluded
+func (s *server) PodsStatus() []PodStatus {
+	statuses := make([]PodStatus, 0)
+	natEntries, err := helpers.CountNatEntries()
+	if err != nil {
+		log.Error().Msgf("failed to count nat entries: %v", err)
+	}
+	for _, pod := range s.interception.ListPods() {
+		if pod.Spec.HostNetwork || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		st := PodStatus{
+			Namespace:   pod.Namespace,
+			Name:        pod.Name,
+			UID:         pod.UID,
+			Intercepted: s.interception.IsIntercepted(pod),
+			NatEntries:  natEntries[pod.Status.PodIP],
+		}
+		np, err := s.getPodNetns(pod.UID)
+		if err == nil {
+			err = getAttachmentStatus(&st, np, "")
+		}
+		if err != nil {
+			st.Error = err.Error()
+		}
+		statuses = append(statuses, st)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Namespace != statuses[j].Namespace {
+			return statuses[i].Namespace < statuses[j].Namespace
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// getAttachmentStatus fills the attachment status of a device of the netns, the default one when dev is empty
+func getAttachmentStatus(st *PodStatus, netnsPath, dev string) error {
+	st.Netns = netnsPath
+	netns, err := ns.GetNS(netnsPath)
+	if err != nil {
+		return err
+	}
+	defer netns.Close() // nolint: errcheck
+
+	return netns.Do(func(_ ns.NetNS) error {
+		var iface *net.Interface
+		if len(dev) != 0 {
+			iface, err = net.InterfaceByName(dev)
+		} else {
+			iface, err = getDefaultDevice()
+		}
+		if err != nil {
+			return fmt.Errorf("%v in %s", err, netnsPath)
+		}
+		st.Device = iface.Name
+
+		if st.Qdisc, err = hasClsactQdisc(iface.Index); err != nil {
+			return err
+		}
+		if st.Ingress, st.Egress, err = getTCFilters(iface.Index); err != nil {
+			return err
+		}
+		st.Attached = st.Qdisc && st.Ingress && st.Egress
+		return nil
+	})
+}
+
+// checkAttachment returns an error describing what is missing when the tc programs are not fully attached
+func checkAttachment(st *PodStatus) error {
+	if st.Attached {
+		return nil
+	}
+	var missing []string
+	if !st.Qdisc {
+		missing = append(missing, "clsact qdisc")
+	}
+	if !st.Ingress {
+		missing = append(missing, "ingress filter")
+	}
+	if !st.Egress {
+		missing = append(missing, "egress filter")
+	}
+	return fmt.Errorf("pod %s/%s is intercepted but %s missing on device %s of %s",
+		st.Namespace, st.Name, strings.Join(missing, ", "), st.Device, st.Netns)
+}
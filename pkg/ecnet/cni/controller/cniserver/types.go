@@ -1,7 +1,12 @@
 // Package cniserver implements ECNET CNI control server.
 package cniserver
 
-import "github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
+)
 
 var (
 	log = logger.New("bridge-ctrl-server")
@@ -10,5 +15,51 @@ var (
 // Server CNI Server.
 type Server interface {
 	Start() error
+	// Stop stops the server, the tc programs are left attached to be taken over by the next instance
 	Stop()
+	// CleanUp detaches the tc programs from all the pods and uninstalls the CNI plugin
+	CleanUp()
+	// SyncPod attaches the tc programs to a running pod when its traffic is intercepted, and detaches them otherwise
+	SyncPod(pod *corev1.Pod) error
+	// ForgetPod drops the state kept for a deleted pod
+	ForgetPod(uid types.UID)
+	// PodsStatus returns the attachment status of the pods of the node
+	PodsStatus() []PodStatus
+}
+
+// Interception tells which pods have their traffic intercepted by the tc programs
+type Interception interface {
+	// IsIntercepted returns whether the traffic of the pod is intercepted
+	IsIntercepted(pod *corev1.Pod) bool
+	// GetPod returns the pod with the given namespace and name
+	GetPod(namespace, name string) (*corev1.Pod, error)
+	// GetPodByUID returns the pod of the node with the given uid
+	GetPodByUID(uid types.UID) (*corev1.Pod, bool)
+	// ListPods returns the pods of the node
+	ListPods() []*corev1.Pod
+}
+
+// NetnsResolver resolves the netns path of a running pod
+type NetnsResolver interface {
+	// GetPodNetns returns the netns path of the pod with the given uid
+	GetPodNetns(uid types.UID) (string, error)
+}
+
+// PodStatus is the attachment status of the tc programs of a pod
+type PodStatus struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+	// Intercepted tells whether the traffic of the pod is to be intercepted
+	Intercepted bool `json:"intercepted"`
+	// Attached tells whether the clsact qdisc and both filters are attached to the device of the pod
+	Attached bool   `json:"attached"`
+	Netns    string `json:"netns,omitempty"`
+	Device   string `json:"device,omitempty"`
+	Qdisc    bool   `json:"qdisc"`
+	Ingress  bool   `json:"ingress"`
+	Egress   bool   `json:"egress"`
+	// NatEntries is the number of entries of the nat maps for the flows of the pod
+	NatEntries int    `json:"natEntries"`
+	Error      string `json:"error,omitempty"`
 }
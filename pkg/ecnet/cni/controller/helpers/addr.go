@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
 )
 
 var (
+	// bridgeIPLock guards the cached cni bridge ip, updated by the datapath config sync
+	// and read by the cni server goroutines
+	bridgeIPLock sync.RWMutex
 	bridgeIPInt  uint32
 	bridgeIPAddr net.IP
 )
@@ -32,33 +36,52 @@ func GetBridgeIP() (ipAddr net.IP, ipInt uint32) {
 }
 
 func waitBridgeIP() (net.IP, uint32, error) {
-	if bridgeIPInt == 0 {
-		found := false
-		if ifaces, err := net.Interfaces(); err == nil {
-			for _, iface := range ifaces {
-				if iface.Flags&net.FlagUp != 0 && strings.HasPrefix(iface.Name, config.BridgeEth) {
-					if addrs, addrErr := iface.Addrs(); addrErr == nil {
-						for _, addr := range addrs {
-							if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
-								if bridgeIPAddr = ipnet.IP.To4(); bridgeIPAddr != nil {
-									bridgeIPInt = binary.BigEndian.Uint32(bridgeIPAddr)
-									found = true
-									break
-								}
-							}
-						}
-					} else {
-						return bridgeIPAddr, bridgeIPInt, fmt.Errorf("unexpected exit err: %v", err)
-					}
-					break
+	if ipAddr, ipInt := getCachedBridgeIP(); ipInt > 0 {
+		return ipAddr, ipInt, nil
+	}
+	ipAddr, ipInt, err := lookupBridgeIP()
+	if err != nil {
+		return nil, 0, err
+	}
+	setBridgeIP(ipAddr, ipInt)
+	return ipAddr, ipInt, nil
+}
+
+func getCachedBridgeIP() (net.IP, uint32) {
+	bridgeIPLock.RLock()
+	defer bridgeIPLock.RUnlock()
+	return bridgeIPAddr, bridgeIPInt
+}
+
+// setBridgeIP caches the cni bridge veth's ipv4 addr
+func setBridgeIP(ipAddr net.IP, ipInt uint32) {
+	bridgeIPLock.Lock()
+	defer bridgeIPLock.Unlock()
+	bridgeIPAddr, bridgeIPInt = ipAddr, ipInt
+}
+
+// lookupBridgeIP retrieves cni bridge veth's ipv4 addr, bypassing the cached one
+func lookupBridgeIP() (net.IP, uint32, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, 0, fmt.Errorf("unexpected exit err: %v", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || !strings.HasPrefix(iface.Name, config.BridgeEth) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, 0, fmt.Errorf("unexpected exit err: %v", err)
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+				if ipAddr := ipnet.IP.To4(); ipAddr != nil {
+					return ipAddr, binary.BigEndian.Uint32(ipAddr), nil
 				}
 			}
-		} else {
-			return bridgeIPAddr, bridgeIPInt, fmt.Errorf("unexpected exit err: %v", err)
-		}
-		if !found {
-			return bridgeIPAddr, bridgeIPInt, fmt.Errorf("unexpected retrieves cni bridge veth[%s]'s ipv4 addr", config.BridgeEth)
 		}
+		break
 	}
-	return bridgeIPAddr, bridgeIPInt, nil
+	return nil, 0, fmt.Errorf("unexpected retrieves cni bridge veth[%s]'s ipv4 addr", config.BridgeEth)
 }
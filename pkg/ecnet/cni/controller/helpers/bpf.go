@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"path/filepath"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+)
+
+// The ebpf objects are compiled from bpf/*.c when building the ecnet-bridge image, see bpf/Makefile.
+// The bindings below follow the layout bpf2go generates, the objects are read from config.BPFObjectsDir,
+// until the ones generated with go:generate, see gen.go, are checked in.
+const (
+	ecnetCniTcObject   = "ecnet_cni_tc.o"
+	ecnetCniOptsObject = "ecnet_cni_opts.o"
+)
+
+// The names of the constants of bpf/headers/ecnet.h, rewritten before loading the objects
+const (
+	constPrintkNewline = "ecnet_printk_newline"
+)
+
+var (
+	// progConstants holds the values of the constants, set by LoadProgs
+	progConstants map[string]interface{}
+)
+
+// ecnetMaps contains the maps shared by all the objects, pinned in config.ECNetEbpfMapPinPath
+type ecnetMaps struct {
+	EcnetConfig  *ebpf.Map `ebpf:"ecnet_config"`
+	EcnetDNSNat  *ebpf.Map `ebpf:"ecnet_dns_nat"`
+	EcnetSvcNat  *ebpf.Map `ebpf:"ecnet_svc_nat"`
+	EcnetUDPNat  *ebpf.Map `ebpf:"ecnet_udp_nat"`
+	EcnetUDPFlow *ebpf.Map `ebpf:"ecnet_udp_flow"`
+	EcnetStats   *ebpf.Map `ebpf:"ecnet_stats"`
+}
+
+// Close closes the maps, they stay pinned
+func (m *ecnetMaps) Close() error {
+	return closeAll(m.EcnetConfig, m.EcnetDNSNat, m.EcnetSvcNat, m.EcnetUDPNat, m.EcnetUDPFlow, m.EcnetStats)
+}
+
+// ecnetCniOptsObjects contains the objects of ecnet_cni_opts.o
+type ecnetCniOptsObjects struct {
+	GetSockopt *ebpf.Program `ebpf:"get_sockopt"`
+
+	ecnetMaps
+}
+
+// Close closes the objects, the pinned ones stay loaded
+func (o *ecnetCniOptsObjects) Close() error {
+	return closeAll(o.GetSockopt, &o.ecnetMaps)
+}
+
+// ecnetCniTcObjects contains the programs of ecnet_cni_tc.o
+type ecnetCniTcObjects struct {
+	Ingress *ebpf.Program `ebpf:"ecnet_cni_tc_ingress"`
+	Egress  *ebpf.Program `ebpf:"ecnet_cni_tc_egress"`
+}
+
+// loadEcnetObjectSpec reads the spec of a precompiled object and rewrites its constants.
+// Its maps are pinned by name, so that all the objects share them, and the nat maps are sized from the config.
+func loadEcnetObjectSpec(object string) (*ebpf.CollectionSpec, error) {
+	spec, err := ebpf.LoadCollectionSpec(filepath.Join(config.BPFObjectsDir, object))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{"ecnet_config", "ecnet_dns_nat", "ecnet_svc_nat", "ecnet_udp_nat", "ecnet_udp_flow", "ecnet_stats"} {
+		mapSpec, ok := spec.Maps[name]
+		if !ok {
+			continue
+		}
+		// The iproute2 map definitions carry trailing fields cilium/ebpf does not know about
+		mapSpec.Extra = nil
+		mapSpec.Pinning = ebpf.PinByName
+		if size := natMapSizes()[name]; size > 0 {
+			mapSpec.MaxEntries = size
+		}
+	}
+
+	if err = spec.RewriteConstants(progConstants); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// natMapSizes returns the configured max entries of the nat maps, by name.
+// The udp maps hold the flows of the imported services as ecnet_svc_nat does.
+func natMapSizes() map[string]uint32 {
+	return map[string]uint32{
+		"ecnet_dns_nat":  config.DNSNatMapSize,
+		"ecnet_svc_nat":  config.SvcNatMapSize,
+		"ecnet_udp_nat":  config.SvcNatMapSize,
+		"ecnet_udp_flow": config.SvcNatMapSize,
+	}
+}
+
+type closer interface {
+	Close() error
+}
+
+// closeAll closes all the non nil closers and returns the first error
+func closeAll(closers ...closer) error {
+	var firstErr error
+	for _, c := range closers {
+		switch c := c.(type) {
+		case *ebpf.Program:
+			if c == nil {
+				continue
+			}
+		case *ebpf.Map:
+			if c == nil {
+				continue
+			}
+		}
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
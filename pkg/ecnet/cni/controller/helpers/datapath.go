@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/announcements"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/configurator"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/errcode"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/messaging"
+)
+
+const (
+	// bridgeIPCheckInterval defines how often the cni bridge ip is checked for changes
+	bridgeIPCheckInterval = 10 * time.Second
+)
+
+// DatapathConfig is the value of the ecnet_config map, it must be kept in sync with
+// struct ecnet_cfg of bpf/headers/maps.h. All the fields are in host byte order.
+type DatapathConfig struct {
+	BridgeIP          uint32
+	ClusterSetVIPNet  uint32
+	ClusterSetVIPMask uint32
+	ProxyPort         uint16
+	UDPProxyPort      uint16
+	DNSProxyPort      uint16
+	DNSCapturePort    uint16
+	Debug             uint8
+	_                 [3]uint8
+}
+
+// newDatapathConfig builds the datapath parameters from the cni bridge ip and EcnetConfig
+func newDatapathConfig(bridgeIP uint32, cfg configurator.Configurator) *DatapathConfig {
+	dpConfig := &DatapathConfig{
+		BridgeIP:       bridgeIP,
+		ProxyPort:      cfg.GetBridgeProxyPort(),
+		UDPProxyPort:   cfg.GetBridgeUDPProxyPort(),
+		DNSProxyPort:   cfg.GetBridgeDNSProxyPort(),
+		DNSCapturePort: cfg.GetBridgeDNSCapturePort(),
+		Debug:          boolToUint8(cfg.IsBridgeKernelTracingEnabled()),
+	}
+
+	vipCIDR := cfg.GetClusterSetVIPCIDR()
+	if len(vipCIDR) == 0 {
+		return dpConfig
+	}
+	_, vipNet, err := net.ParseCIDR(vipCIDR)
+	if err != nil || vipNet.IP.To4() == nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrInvalidClusterSetVIPCIDR)).
+			Msgf("Invalid cluster set vip cidr %s, expected an IPv4 CIDR", vipCIDR)
+		return dpConfig
+	}
+	dpConfig.ClusterSetVIPNet = binary.BigEndian.Uint32(vipNet.IP.To4())
+	dpConfig.ClusterSetVIPMask = binary.BigEndian.Uint32(net.IP(vipNet.Mask).To4())
+	return dpConfig
+}
+
+// UpdateDatapathConfig writes the datapath parameters into the pinned ecnet_config map
+func UpdateDatapathConfig(dpConfig *DatapathConfig) error {
+	configMap := GetEcnetConfigMap()
+	if configMap == nil {
+		return fmt.Errorf("map[%s] is not loaded", config.ECNetConfigEbpfMap)
+	}
+	return configMap.Put(uint32(0), dpConfig)
+}
+
+// SyncDatapathConfig writes the datapath parameters at startup, then updates them live
+// whenever EcnetConfig or the cni bridge ip changes, until stop is closed.
+func SyncDatapathConfig(cfg configurator.Configurator, msgBroker *messaging.Broker, stop <-chan struct{}) error {
+	kubePubSub := msgBroker.GetKubeEventPubSub()
+	updateChan := kubePubSub.Sub(
+		announcements.EcnetConfigAdded.String(),
+		announcements.EcnetConfigUpdated.String(),
+	)
+
+	_, bridgeIP := GetBridgeIP()
+	current := newDatapathConfig(bridgeIP, cfg)
+	if err := UpdateDatapathConfig(current); err != nil {
+		msgBroker.Unsub(kubePubSub, updateChan)
+		return err
+	}
+	log.Info().Msgf("datapath config: %+v", *current)
+
+	go func() {
+		defer msgBroker.Unsub(kubePubSub, updateChan)
+		ticker := time.NewTicker(bridgeIPCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			var updated *DatapathConfig
+			select {
+			case <-stop:
+				log.Info().Msg("Received stop signal, exiting datapath config sync routine")
+				return
+
+			case <-updateChan:
+				updated = newDatapathConfig(current.BridgeIP, cfg)
+
+			case <-ticker.C:
+				ipAddr, ipInt, err := lookupBridgeIP()
+				if err != nil {
+					log.Warn().Msgf("fail retrieving cni bridge veth[%s]'s ipv4 addr: %v", config.BridgeEth, err)
+					continue
+				}
+				if ipInt == current.BridgeIP {
+					continue
+				}
+				log.Info().Msgf("cni bridge veth[%s]'s ipv4 addr changed to %s", config.BridgeEth, ipAddr)
+				setBridgeIP(ipAddr, ipInt)
+				dpConfig := *current
+				dpConfig.BridgeIP = ipInt
+				updated = &dpConfig
+			}
+
+			if *updated == *current {
+				continue
+			}
+			if err := UpdateDatapathConfig(updated); err != nil {
+				log.Error().Msgf("update datapath config error: %v", err)
+				continue
+			}
+			log.Info().Msgf("datapath config: %+v", *updated)
+			current = updated
+		}
+	}()
+	return nil
+}
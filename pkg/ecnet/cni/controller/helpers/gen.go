@@ -0,0 +1,6 @@
+package helpers
+
+// The bindings of the ebpf objects are generated by bpf2go from bpf/*.c, run `make bpf-generate`.
+// They take over the hand-written ones of bpf.go, which are removed when the generated files are checked in.
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -cflags "-O2 -g -Wall" -target bpfel,bpfeb ecnetCniTc ../../../../../bpf/ecnet_cni_tc.c -- -I../../../../../bpf/headers
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -cflags "-O2 -g -Wall" -target bpfel,bpfeb ecnetCniOpts ../../../../../bpf/ecnet_cni_opts.c -- -I../../../../../bpf/headers
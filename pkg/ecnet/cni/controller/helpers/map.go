@@ -10,24 +10,52 @@ import (
 )
 
 var (
-	mcsDNSNatMap *ebpf.Map
-	mcsSvcNatMap *ebpf.Map
+	ecnetConfigMap *ebpf.Map
+	mcsDNSNatMap   *ebpf.Map
+	mcsSvcNatMap   *ebpf.Map
+	udpNatMap      *ebpf.Map
+	udpFlowMap     *ebpf.Map
+	ecnetStatsMap  *ebpf.Map
 )
 
 // InitLoadPinnedMap init, load and pinned mapsß
 func InitLoadPinnedMap() error {
 	var err error
+	ecnetConfigMap, err = ebpf.LoadPinnedMap(config.ECNetConfigEbpfMap, &ebpf.LoadPinOptions{})
+	if err != nil {
+		return fmt.Errorf("load map[%s] error: %v", config.ECNetConfigEbpfMap, err)
+	}
 	mcsDNSNatMap, err = ebpf.LoadPinnedMap(config.ECNetDNSNatEbpfMap, &ebpf.LoadPinOptions{})
 	if err != nil {
 		return fmt.Errorf("load map[%s] error: %v", config.ECNetDNSNatEbpfMap, err)
 	}
 	mcsSvcNatMap, err = ebpf.LoadPinnedMap(config.ECNetSVCNatEbpfMap, &ebpf.LoadPinOptions{})
 	if err != nil {
-		return fmt.Errorf("load map[%s] error: %v", err, config.ECNetSVCNatEbpfMap)
+		return fmt.Errorf("load map[%s] error: %v", config.ECNetSVCNatEbpfMap, err)
+	}
+	udpNatMap, err = ebpf.LoadPinnedMap(config.ECNetUDPNatEbpfMap, &ebpf.LoadPinOptions{})
+	if err != nil {
+		return fmt.Errorf("load map[%s] error: %v", config.ECNetUDPNatEbpfMap, err)
+	}
+	udpFlowMap, err = ebpf.LoadPinnedMap(config.ECNetUDPFlowEbpfMap, &ebpf.LoadPinOptions{})
+	if err != nil {
+		return fmt.Errorf("load map[%s] error: %v", config.ECNetUDPFlowEbpfMap, err)
+	}
+	ecnetStatsMap, err = ebpf.LoadPinnedMap(config.ECNetStatsEbpfMap, &ebpf.LoadPinOptions{})
+	if err != nil {
+		return fmt.Errorf("load map[%s] error: %v", config.ECNetStatsEbpfMap, err)
 	}
 	return nil
 }
 
+// GetEcnetConfigMap returns datapath config map
+func GetEcnetConfigMap() *ebpf.Map {
+	if ecnetConfigMap == nil {
+		_ = InitLoadPinnedMap()
+	}
+	return ecnetConfigMap
+}
+
 // GetMcsDNSNatMap returns pod fib map
 func GetMcsDNSNatMap() *ebpf.Map {
 	if mcsDNSNatMap == nil {
@@ -43,3 +71,27 @@ func GetMcsSvcNatMap() *ebpf.Map {
 	}
 	return mcsSvcNatMap
 }
+
+// GetUDPNatMap returns udp nat map
+func GetUDPNatMap() *ebpf.Map {
+	if udpNatMap == nil {
+		_ = InitLoadPinnedMap()
+	}
+	return udpNatMap
+}
+
+// GetUDPFlowMap returns udp flow map
+func GetUDPFlowMap() *ebpf.Map {
+	if udpFlowMap == nil {
+		_ = InitLoadPinnedMap()
+	}
+	return udpFlowMap
+}
+
+// GetEcnetStatsMap returns datapath counters map
+func GetEcnetStatsMap() *ebpf.Map {
+	if ecnetStatsMap == nil {
+		_ = InitLoadPinnedMap()
+	}
+	return ecnetStatsMap
+}
@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The indexes of the counters of ecnet_stats, see enum ecnet_stat of bpf/headers/maps.h
+const (
+	statSvcNatMiss         uint32 = 0
+	statDNSNatMiss         uint32 = 1
+	statSvcNatHit          uint32 = 2
+	statDNSNatHit          uint32 = 3
+	statIngressRewrite     uint32 = 4
+	statEgressRewrite      uint32 = 5
+	statDNSRedirect        uint32 = 6
+	statOriginalDstRewrite uint32 = 7
+	statIngressDrop        uint32 = 8
+	statEgressDrop         uint32 = 9
+	statUDPNatMiss         uint32 = 10
+	statUDPNatHit          uint32 = 11
+	statSvcNatInsert       uint32 = 12
+	statDNSNatInsert       uint32 = 13
+	statUDPNatInsert       uint32 = 14
+	statSvcNatDelete       uint32 = 15
+)
+
+var (
+	natEntryDesc = prometheus.NewDesc(
+		"ecnet_bridge_nat_entries",
+		"Represents the number of entries of a nat map",
+		[]string{"map"}, nil)
+
+	natLookupMissDesc = prometheus.NewDesc(
+		"ecnet_bridge_nat_lookup_miss_total",
+		"Represents the number of packets of the datapath whose nat entry was not found",
+		[]string{"map"}, nil)
+
+	natLookupHitDesc = prometheus.NewDesc(
+		"ecnet_bridge_nat_lookup_hit_total",
+		"Represents the number of packets of the datapath whose nat entry was found",
+		[]string{"map"}, nil)
+
+	rewrittenPacketDesc = prometheus.NewDesc(
+		"ecnet_bridge_datapath_rewritten_packets_total",
+		"Represents the number of packets rewritten by the tc programs",
+		[]string{"direction"}, nil)
+
+	droppedPacketDesc = prometheus.NewDesc(
+		"ecnet_bridge_datapath_dropped_packets_total",
+		"Represents the number of packets dropped by the tc programs",
+		[]string{"direction"}, nil)
+
+	dnsRedirectDesc = prometheus.NewDesc(
+		"ecnet_bridge_datapath_dns_redirect_total",
+		"Represents the number of DNS queries redirected to the bridge DNS proxy",
+		nil, nil)
+
+	originalDstRewriteDesc = prometheus.NewDesc(
+		"ecnet_bridge_datapath_original_dst_rewrite_total",
+		"Represents the number of getsockopt(SO_ORIGINAL_DST) calls answered with the original destination",
+		nil, nil)
+)
+
+// datapathCounters maps the counters of ecnet_stats to their metric and label values
+var datapathCounters = []struct {
+	stat   uint32
+	desc   *prometheus.Desc
+	labels []string
+}{
+	{stat: statSvcNatMiss, desc: natLookupMissDesc, labels: []string{"ecnet_svc_nat"}},
+	{stat: statDNSNatMiss, desc: natLookupMissDesc, labels: []string{"ecnet_dns_nat"}},
+	{stat: statSvcNatHit, desc: natLookupHitDesc, labels: []string{"ecnet_svc_nat"}},
+	{stat: statDNSNatHit, desc: natLookupHitDesc, labels: []string{"ecnet_dns_nat"}},
+	{stat: statUDPNatMiss, desc: natLookupMissDesc, labels: []string{"ecnet_udp_nat"}},
+	{stat: statUDPNatHit, desc: natLookupHitDesc, labels: []string{"ecnet_udp_nat"}},
+	{stat: statIngressRewrite, desc: rewrittenPacketDesc, labels: []string{"ingress"}},
+	{stat: statEgressRewrite, desc: rewrittenPacketDesc, labels: []string{"egress"}},
+	{stat: statIngressDrop, desc: droppedPacketDesc, labels: []string{"ingress"}},
+	{stat: statEgressDrop, desc: droppedPacketDesc, labels: []string{"egress"}},
+	{stat: statDNSRedirect, desc: dnsRedirectDesc},
+	{stat: statOriginalDstRewrite, desc: originalDstRewriteDesc},
+}
+
+// datapathMetricsCollector reads the metrics of the datapath from the pinned maps at scrape time
+type datapathMetricsCollector struct{}
+
+// DatapathMetricsCollector returns the collector for the metrics of the nat maps and the counters of the datapath
+func DatapathMetricsCollector() prometheus.Collector {
+	return &datapathMetricsCollector{}
+}
+
+// Describe implements prometheus.Collector
+func (mc *datapathMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- natEntryDesc
+	ch <- natLookupMissDesc
+	ch <- natLookupHitDesc
+	ch <- rewrittenPacketDesc
+	ch <- droppedPacketDesc
+	ch <- dnsRedirectDesc
+	ch <- originalDstRewriteDesc
+}
+
+// Collect implements prometheus.Collector
+func (mc *datapathMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, natMap := range natMaps() {
+		if natMap == nil {
+			continue
+		}
+		count, err := countNatMapEntries(natMap)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to count the entries of map[%s]", name)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(natEntryDesc, prometheus.GaugeValue, float64(count), name)
+	}
+
+	for _, counter := range datapathCounters {
+		value, err := readStat(counter.stat)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to read the counter %d of the datapath", counter.stat)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(counter.desc, prometheus.CounterValue, float64(value), counter.labels...)
+	}
+}
+
+// readStat returns the sum of the per cpu values of a counter of ecnet_stats
+func readStat(stat uint32) (uint64, error) {
+	statsMap := GetEcnetStatsMap()
+	if statsMap == nil {
+		return 0, ErrStatsNotLoaded
+	}
+	var values []uint64
+	if err := statsMap.Lookup(&stat, &values); err != nil {
+		return 0, err
+	}
+	var sum uint64
+	for _, v := range values {
+		sum += v
+	}
+	return sum, nil
+}
@@ -0,0 +1,266 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"golang.org/x/sys/unix"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/metricsstore"
+)
+
+const (
+	// natSweepInterval is how often the nat maps are swept. It is also the grace period of the entries
+	// of the pods unknown to the sweeper, the ip of a new pod may not be reported yet.
+	natSweepInterval = 30 * time.Second
+)
+
+// The reasons the entries of the nat maps are evicted for
+const (
+	natEvictionIdle       = "idle"
+	natEvictionPodDeleted = "pod_deleted"
+	natEvictionLRU        = "lru"
+)
+
+// natMapStat holds the counters of ecnet_stats accounting for the entries of a nat map
+type natMapStat struct {
+	inserted uint32
+	// deleted is the counter of the entries deleted by the datapath, valid if hasDeleted
+	deleted    uint32
+	hasDeleted bool
+}
+
+// natMapStats returns the counters accounting for the entries of the nat maps, by name
+func natMapStats() map[string]natMapStat {
+	return map[string]natMapStat{
+		"ecnet_dns_nat": {inserted: statDNSNatInsert},
+		"ecnet_svc_nat": {inserted: statSvcNatInsert, deleted: statSvcNatDelete, hasDeleted: true},
+		"ecnet_udp_nat": {inserted: statUDPNatInsert},
+	}
+}
+
+// natLRUTracker derives the number of entries a nat map evicted by its LRU, which the kernel doesn't report,
+// from the entries inserted and deleted since the previous sweep
+type natLRUTracker struct {
+	synced   bool
+	inserted uint64
+	deleted  uint64
+	entries  int
+}
+
+// evicted returns the number of entries evicted by the LRU since the previous call,
+// given the entries left in the map and the ones deleted by the sweeper meanwhile
+func (t *natLRUTracker) evicted(stat natMapStat, entries, swept int) (int, error) {
+	inserted, err := readStat(stat.inserted)
+	if err != nil {
+		return 0, err
+	}
+	var deleted uint64
+	if stat.hasDeleted {
+		if deleted, err = readStat(stat.deleted); err != nil {
+			return 0, err
+		}
+	}
+
+	evicted := 0
+	if t.synced {
+		// The counters and the entries are not read at once, a negative delta is the datapath running ahead
+		evicted = int(inserted-t.inserted) - int(deleted-t.deleted) - swept - (entries - t.entries)
+		if evicted < 0 {
+			evicted = 0
+		}
+	}
+	t.synced, t.inserted, t.deleted, t.entries = true, inserted, deleted, entries
+	return evicted, nil
+}
+
+// countNatMapEntries returns the number of entries of a nat map
+func countNatMapEntries(natMap *ebpf.Map) (int, error) {
+	count := 0
+	var pair natPair
+	var origin natOrigin
+	entries := natMap.Iterate()
+	for entries.Next(&pair, &origin) {
+		count++
+	}
+	return count, entries.Err()
+}
+
+// ipv4 is an IPv4 address as found in the packets, in network byte order
+type ipv4 [4]byte
+
+// String returns the dotted representation of the address
+func (ip ipv4) String() string {
+	return net.IP(ip[:]).String()
+}
+
+// natPair mirrors struct pair, the key of the nat maps
+type natPair struct {
+	SrcIP   ipv4
+	DstIP   ipv4
+	SrcPort uint16
+	DstPort uint16
+}
+
+// natOrigin mirrors struct origin_info, the value of the nat maps.
+// The values of ecnet_udp_nat, struct udp_origin_info, have the same layout
+// with the port of the pod socket in place of Proto.
+type natOrigin struct {
+	IP       ipv4
+	Port     uint16
+	Proto    uint16
+	LastSeen uint64
+}
+
+// natMaps returns the nat maps by name
+func natMaps() map[string]*ebpf.Map {
+	return map[string]*ebpf.Map{
+		"ecnet_dns_nat": GetMcsDNSNatMap(),
+		"ecnet_svc_nat": GetMcsSvcNatMap(),
+		"ecnet_udp_nat": GetUDPNatMap(),
+	}
+}
+
+// natIdleTimeouts returns how long the entries of a nat map are kept once idle, 0 when forever
+func natIdleTimeouts(name string) func(origin natOrigin) time.Duration {
+	if name == "ecnet_udp_nat" {
+		return func(natOrigin) time.Duration {
+			return config.UDPNatIdleTimeout
+		}
+	}
+	return func(origin natOrigin) time.Duration {
+		return natIdleTimeout(origin.Proto)
+	}
+}
+
+// natIdleTimeout returns how long the entries of the given protocol are kept once idle, 0 when forever
+func natIdleTimeout(proto uint16) time.Duration {
+	switch proto {
+	case unix.IPPROTO_TCP:
+		return config.TCPNatIdleTimeout
+	case unix.IPPROTO_UDP:
+		return config.UDPNatIdleTimeout
+	default:
+		return 0
+	}
+}
+
+// SweepNatEntries deletes the entries of a nat map which are idle for longer than their timeout,
+// or which belong to a pod no longer running on the node. The pod of an entry is the one the packets of the
+// flow come from, whose ip is the destination of the key. The TCP entries are otherwise deleted when the
+// connection is closed, their timeout only collects the connections whose close was missed.
+// It returns the number of deleted entries by reason.
+func SweepNatEntries(natMap *ebpf.Map, idleTimeout func(origin natOrigin) time.Duration, isPodRunning func(ip string) bool) (map[string]int, error) {
+	// bpf_ktime_get_ns() reads the monotonic clock
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return nil, err
+	}
+	now := uint64(ts.Nano())
+
+	stale := make(map[natPair]string)
+	var pair natPair
+	var origin natOrigin
+	entries := natMap.Iterate()
+	for entries.Next(&pair, &origin) {
+		var idle time.Duration
+		if now > origin.LastSeen {
+			idle = time.Duration(now - origin.LastSeen)
+		}
+		if timeout := idleTimeout(origin); timeout > 0 && idle > timeout {
+			stale[pair] = natEvictionIdle
+		} else if idle > natSweepInterval && !isPodRunning(pair.DstIP.String()) {
+			stale[pair] = natEvictionPodDeleted
+		}
+	}
+	if err := entries.Err(); err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[string]int)
+	for pair, reason := range stale {
+		pair := pair
+		if err := natMap.Delete(&pair); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return deleted, err
+		}
+		deleted[reason]++
+	}
+	return deleted, nil
+}
+
+// RunNatSweeper sweeps the nat maps every natSweepInterval until stop is closed,
+// the pods are looked up by ip through isPodRunning
+func RunNatSweeper(isPodRunning func(ip string) bool, stop <-chan struct{}) {
+	lruTrackers := make(map[string]*natLRUTracker)
+	ticker := time.NewTicker(natSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		for name, natMap := range natMaps() {
+			if natMap == nil {
+				log.Error().Msgf("map[%s] not loaded", name)
+				continue
+			}
+			deleted, err := SweepNatEntries(natMap, natIdleTimeouts(name), isPodRunning)
+			swept := 0
+			for reason, count := range deleted {
+				swept += count
+				metricsstore.DefaultMetricsStore.BridgeNatEvictionCounter.WithLabelValues(name, reason).Add(float64(count))
+				log.Debug().Msgf("evicted %d %s entries of map[%s]", count, reason, name)
+			}
+			if err != nil {
+				log.Error().Err(err).Msgf("failed to sweep map[%s]", name)
+				continue
+			}
+
+			entries, err := countNatMapEntries(natMap)
+			if err != nil {
+				log.Error().Err(err).Msgf("failed to count the entries of map[%s]", name)
+				continue
+			}
+			tracker, ok := lruTrackers[name]
+			if !ok {
+				tracker = new(natLRUTracker)
+				lruTrackers[name] = tracker
+			}
+			evicted, err := tracker.evicted(natMapStats()[name], entries, swept)
+			if err != nil {
+				log.Error().Err(err).Msgf("failed to read the counters of map[%s]", name)
+				continue
+			}
+			if evicted > 0 {
+				metricsstore.DefaultMetricsStore.BridgeNatEvictionCounter.WithLabelValues(name, natEvictionLRU).Add(float64(evicted))
+				log.Debug().Msgf("evicted %d %s entries of map[%s]", evicted, natEvictionLRU, name)
+			}
+		}
+	}
+}
+
+// CountNatEntries returns the number of entries of the nat maps by pod ip
+func CountNatEntries() (map[string]int, error) {
+	counts := make(map[string]int)
+	for name, natMap := range natMaps() {
+		if natMap == nil {
+			return nil, fmt.Errorf("map[%s] not loaded", name)
+		}
+		var pair natPair
+		var origin natOrigin
+		entries := natMap.Iterate()
+		for entries.Next(&pair, &origin) {
+			counts[pair.DstIP.String()]++
+		}
+		if err := entries.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
@@ -1,67 +1,238 @@
 package helpers
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"strings"
 
 	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"golang.org/x/sys/unix"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+)
+
+const (
+	bpfFSPath = "/sys/fs/bpf"
 )
 
-// LoadProgs load ebpf progs
-func LoadProgs(kernelTracing bool) error {
+var (
+	getSockoptLink link.Link
+)
+
+// LoadProgs loads the precompiled ebpf objects, and pins the maps they share as well as the getsockopt prog.
+// The programs pass all the traffic through until the datapath parameters are written, see SyncDatapathConfig.
+func LoadProgs() error {
 	if os.Getuid() != 0 {
-		return fmt.Errorf("root user in required for this process or container")
+		return ErrNotRoot
 	}
-	cmd := exec.Command("make", "load")
-	cmd.Env = os.Environ()
-	if !kernelTracing {
-		cmd.Env = append(cmd.Env, "DEBUG=0")
+
+	progConstants = map[string]interface{}{
+		// See https://nakryiko.com/posts/bpf-tips-printk/, kernel will auto print newline if version greater than 5.9.0
+		constPrintkNewline: boolToUint8(!kernelVersionAtLeast(5, 9)),
 	}
 
-	if _, bridgeIP := GetBridgeIP(); bridgeIP > 0 {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("BRIDGE_IP=%d", bridgeIP))
-	} else {
-		return fmt.Errorf("unexpected exit err: retrieves cni bridge veth's ipv4 addr")
+	if err := mountBPFFS(); err != nil {
+		return &ProgError{Op: "mount", Object: bpfFSPath, Err: err}
+	}
+	if err := os.MkdirAll(config.ECNetEbpfMapPinPath, 0750); err != nil {
+		return &ProgError{Op: "mkdir", Object: config.ECNetEbpfMapPinPath, Err: err}
 	}
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if code := cmd.ProcessState.ExitCode(); code != 0 || err != nil {
-		return fmt.Errorf("unexpected exit code: %d, err: %v", code, err)
+	spec, err := loadEcnetObjectSpec(ecnetCniOptsObject)
+	if err != nil {
+		return &ProgError{Op: "read", Object: ecnetCniOptsObject, Err: err}
+	}
+	if err = unpinChangedMaps(spec); err != nil {
+		return err
+	}
+	var objs ecnetCniOptsObjects
+	if err = spec.LoadAndAssign(&objs, &ebpf.CollectionOptions{
+		Maps: ebpf.MapOptions{PinPath: config.ECNetEbpfMapPinPath},
+	}); err != nil {
+		return &ProgError{Op: "load", Object: ecnetCniOptsObject, Err: err}
+	}
+	defer objs.Close() // nolint: errcheck
+
+	// Replace the prog pinned by a previous instance
+	if err = os.Remove(config.ECNetGetSockoptEbpfProg); err != nil && !os.IsNotExist(err) {
+		return &ProgError{Op: "unpin", Object: config.ECNetGetSockoptEbpfProg, Err: err}
+	}
+	if err = objs.GetSockopt.Pin(config.ECNetGetSockoptEbpfProg); err != nil {
+		return &ProgError{Op: "pin", Object: config.ECNetGetSockoptEbpfProg, Err: err}
 	}
 	return nil
 }
 
-// AttachProgs attach ebpf progs
+// AttachProgs attaches the pinned getsockopt prog to cgroup2
 func AttachProgs() error {
 	if os.Getuid() != 0 {
-		return fmt.Errorf("root user in required for this process or container")
+		return ErrNotRoot
+	}
+
+	prog, err := ebpf.LoadPinnedProgram(config.ECNetGetSockoptEbpfProg, nil)
+	if err != nil {
+		return &ProgError{Op: "attach", Object: config.ECNetGetSockoptEbpfProg, Err: err}
 	}
-	cmd := exec.Command("make", "attach")
-	cmd.Env = os.Environ()
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if code := cmd.ProcessState.ExitCode(); code != 0 || err != nil {
-		return fmt.Errorf("unexpected exit code: %d, err: %v", code, err)
+	defer prog.Close() // nolint: errcheck
+
+	// The link pinned by a previous instance is updated in place, so that getsockopt is never left unhandled
+	if pinnedLink, err := link.LoadPinnedLink(config.ECNetGetSockoptEbpfLink, nil); err == nil {
+		if err = pinnedLink.Update(prog); err != nil {
+			_ = pinnedLink.Close()
+			return &ProgError{Op: "attach", Object: config.ECNetGetSockoptEbpfLink, Err: err}
+		}
+		getSockoptLink = pinnedLink
+		return nil
+	}
+
+	cgroupPath, err := getCgroup2Path()
+	if err != nil {
+		return &ProgError{Op: "attach", Object: config.ECNetGetSockoptEbpfProg, Err: err}
+	}
+	l, err := link.AttachCgroup(link.CgroupOptions{
+		Path:    cgroupPath,
+		Attach:  ebpf.AttachCGroupGetsockopt,
+		Program: prog,
+	})
+	if err != nil {
+		return &ProgError{Op: "attach", Object: cgroupPath, Err: err}
 	}
+	// Kernels without bpf links only support the attachment living as long as this process
+	if err = l.Pin(config.ECNetGetSockoptEbpfLink); err != nil && !errors.Is(err, ebpf.ErrNotSupported) {
+		_ = l.Close()
+		return &ProgError{Op: "pin", Object: config.ECNetGetSockoptEbpfLink, Err: err}
+	}
+	getSockoptLink = l
 	return nil
 }
 
-// UnLoadProgs unload ebpf progs
+// UnLoadProgs detaches the getsockopt prog and unpins the ebpf objects.
+// It goes through all the objects and returns the first error.
 func UnLoadProgs() error {
-	cmd := exec.Command("make", "-k", "clean")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if code := cmd.ProcessState.ExitCode(); code != 0 || err != nil {
-		return fmt.Errorf("unload unexpected exit code: %d, err: %v", code, err)
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if getSockoptLink == nil {
+		if pinnedLink, err := link.LoadPinnedLink(config.ECNetGetSockoptEbpfLink, nil); err == nil {
+			getSockoptLink = pinnedLink
+		}
+	}
+	if getSockoptLink != nil {
+		if err := getSockoptLink.Unpin(); err != nil && !errors.Is(err, ebpf.ErrNotSupported) {
+			fail(&ProgError{Op: "unpin", Object: config.ECNetGetSockoptEbpfLink, Err: err})
+		}
+		if err := getSockoptLink.Close(); err != nil {
+			fail(&ProgError{Op: "detach", Object: config.ECNetGetSockoptEbpfLink, Err: err})
+		}
+		getSockoptLink = nil
+	}
+
+	for _, pin := range []string{config.ECNetGetSockoptEbpfProg, config.ECNetConfigEbpfMap, config.ECNetDNSNatEbpfMap, config.ECNetSVCNatEbpfMap,
+		config.ECNetUDPNatEbpfMap, config.ECNetUDPFlowEbpfMap, config.ECNetStatsEbpfMap} {
+		if err := os.Remove(pin); err != nil && !os.IsNotExist(err) {
+			fail(&ProgError{Op: "unpin", Object: pin, Err: err})
+		}
+	}
+	return firstErr
+}
+
+// unpinChangedMaps unpins the maps kept from a previous instance whose size, or value layout, no longer matches
+// the spec, so that they are created again, their entries are lost. The nat maps are resized from the config,
+// the layout of ecnet_config changes with the datapath parameters.
+func unpinChangedMaps(spec *ebpf.CollectionSpec) error {
+	for name, pin := range map[string]string{
+		"ecnet_config":   config.ECNetConfigEbpfMap,
+		"ecnet_dns_nat":  config.ECNetDNSNatEbpfMap,
+		"ecnet_svc_nat":  config.ECNetSVCNatEbpfMap,
+		"ecnet_udp_nat":  config.ECNetUDPNatEbpfMap,
+		"ecnet_udp_flow": config.ECNetUDPFlowEbpfMap,
+		"ecnet_stats":    config.ECNetStatsEbpfMap,
+	} {
+		mapSpec, ok := spec.Maps[name]
+		if !ok {
+			continue
+		}
+		pinned, err := ebpf.LoadPinnedMap(pin, nil)
+		if err != nil {
+			continue
+		}
+		size, valueSize := pinned.MaxEntries(), pinned.ValueSize()
+		_ = pinned.Close()
+		if size == mapSpec.MaxEntries && valueSize == mapSpec.ValueSize {
+			continue
+		}
+		log.Warn().Msgf("map %s changed from %d entries of %d bytes to %d entries of %d bytes, the existing entries are dropped",
+			name, size, valueSize, mapSpec.MaxEntries, mapSpec.ValueSize)
+		if err = os.Remove(pin); err != nil && !os.IsNotExist(err) {
+			return &ProgError{Op: "unpin", Object: pin, Err: err}
+		}
 	}
 	return nil
 }
 
+// mountBPFFS mounts the bpf filesystem unless it is already mounted, as it is when the bpffs of the host
+// is mounted into the pod, so that the pinned objects outlive ecnet-bridge
+func mountBPFFS() error {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(bpfFSPath, &fs); err == nil && fs.Type == unix.BPF_FS_MAGIC {
+		return nil
+	}
+	return unix.Mount("bpf", bpfFSPath, "bpf", 0, "")
+}
+
+// getCgroup2Path returns the cgroup2 mount of the node
+func getCgroup2Path() (string, error) {
+	if len(config.CGroup2Path) > 0 {
+		return config.CGroup2Path, nil
+	}
+
+	mounts, err := os.Open("/proc/mounts")
+	if err != nil {
+		return "", err
+	}
+	defer mounts.Close() // nolint: errcheck
+
+	scanner := bufio.NewScanner(mounts)
+	for scanner.Scan() {
+		// device mountpoint fstype options dump pass
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 2 && fields[2] == "cgroup2" && !strings.HasPrefix(fields[1], "/host") {
+			return fields[1], nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", ErrNoCgroup2
+}
+
+// kernelVersionAtLeast checks whether the running kernel is at least major.minor
+func kernelVersionAtLeast(major, minor int) bool {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err != nil {
+		return false
+	}
+	var kernelMajor, kernelMinor int
+	if _, err := fmt.Sscanf(unix.ByteSliceToString(uname.Release[:]), "%d.%d", &kernelMajor, &kernelMinor); err != nil {
+		return false
+	}
+	return kernelMajor > major || (kernelMajor == major && kernelMinor >= minor)
+}
+
+func boolToUint8(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 var (
 	ingress *ebpf.Program
 	egress  *ebpf.Program
@@ -90,25 +261,25 @@ func GetTrafficControlEgressProg() *ebpf.Program {
 }
 
 func initTrafficControlProgs() error {
-	coll, err := ebpf.LoadCollectionSpec("bpf/ecnet_cni_tc.o")
+	spec, err := loadEcnetObjectSpec(ecnetCniTcObject)
 	if err != nil {
-		return err
+		return &ProgError{Op: "read", Object: ecnetCniTcObject, Err: err}
 	}
-	type progs struct {
-		Ingress *ebpf.Program `ebpf:"ecnet_cni_tc_ingress"`
-		Egress  *ebpf.Program `ebpf:"ecnet_cni_tc_egress"`
-	}
-	ps := progs{}
-	err = coll.LoadAndAssign(&ps, &ebpf.CollectionOptions{
+	var objs ecnetCniTcObjects
+	err = spec.LoadAndAssign(&objs, &ebpf.CollectionOptions{
 		MapReplacements: map[string]*ebpf.Map{
-			"ecnet_dns_nat": GetMcsDNSNatMap(),
-			"ecnet_svc_nat": GetMcsSvcNatMap(),
+			"ecnet_config":   GetEcnetConfigMap(),
+			"ecnet_dns_nat":  GetMcsDNSNatMap(),
+			"ecnet_svc_nat":  GetMcsSvcNatMap(),
+			"ecnet_udp_nat":  GetUDPNatMap(),
+			"ecnet_udp_flow": GetUDPFlowMap(),
+			"ecnet_stats":    GetEcnetStatsMap(),
 		},
 	})
 	if err != nil {
-		return err
+		return &ProgError{Op: "load", Object: ecnetCniTcObject, Err: err}
 	}
-	ingress = ps.Ingress
-	egress = ps.Egress
+	ingress = objs.Ingress
+	egress = objs.Egress
 	return nil
 }
@@ -1,8 +1,44 @@
 // Package helpers implements ebpf helpers.
 package helpers
 
-import "github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
+)
 
 var (
 	log = logger.New("bridge-helpers")
 )
+
+var (
+	// ErrNotRoot is returned when the ebpf objects are managed by a non root user
+	ErrNotRoot = errors.New("root user in required for this process or container")
+
+	// ErrNoCgroup2 is returned when no cgroup2 mount is found to attach the getsockopt prog to
+	ErrNoCgroup2 = errors.New("cgroup2 is not mounted, please enable it or specify its path")
+
+	// ErrStatsNotLoaded is returned when the datapath counters are read before the ecnet_stats map is loaded
+	ErrStatsNotLoaded = errors.New("map[ecnet_stats] not loaded")
+)
+
+// ProgError is returned when an operation on an ebpf object fails
+type ProgError struct {
+	// Op is the operation that failed, ie: load, pin, attach
+	Op string
+	// Object is the ebpf object or the path the operation applies to
+	Object string
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the string representation of the ProgError
+func (e *ProgError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Object, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ProgError) Unwrap() error {
+	return e.Err
+}
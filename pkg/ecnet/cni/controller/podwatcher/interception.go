@@ -0,0 +1,215 @@
+package podwatcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformer "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+)
+
+const (
+	podUIDIndex = "uid"
+	podIPIndex  = "ip"
+
+	resyncInterval = 30 * time.Second
+	// podGetTimeout bounds the time the CNI plugin waits for a pod missing from the cache
+	podGetTimeout = 3 * time.Second
+)
+
+// Interception decides which pods of the node have their traffic intercepted by the tc programs.
+// A pod is intercepted when its flomesh.io/sidecar-injection annotation, or the one of its namespace
+// when the pod does not set it, is enabled, and neither the pod nor its namespace is marked with flomesh.io/ignore.
+// Without any annotation, the pod is intercepted if config.InterceptByDefault is set.
+// The pods of the excluded namespaces are never intercepted.
+type Interception struct {
+	client     kubernetes.Interface
+	pods       cache.SharedIndexInformer
+	namespaces cache.SharedIndexInformer
+}
+
+// NewInterception starts watching the pods of the node and the namespaces, and waits for their caches to sync
+func NewInterception(client kubernetes.Interface, stop <-chan struct{}) (*Interception, error) {
+	nodeName, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	selectByNode := ""
+	if !config.IsKind {
+		selectByNode = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+	}
+	podInformerFactory := kubeinformer.NewFilteredSharedInformerFactory(
+		client, resyncInterval, metav1.NamespaceAll,
+		func(o *metav1.ListOptions) {
+			o.FieldSelector = selectByNode
+		},
+	)
+	nsInformerFactory := kubeinformer.NewSharedInformerFactory(client, resyncInterval)
+
+	i := &Interception{
+		client:     client,
+		pods:       podInformerFactory.Core().V1().Pods().Informer(),
+		namespaces: nsInformerFactory.Core().V1().Namespaces().Informer(),
+	}
+	if err := i.pods.AddIndexers(cache.Indexers{
+		podUIDIndex: func(obj interface{}) ([]string, error) {
+			if pod, ok := obj.(*v1.Pod); ok {
+				return []string{string(pod.UID)}, nil
+			}
+			return nil, nil
+		},
+		podIPIndex: func(obj interface{}) ([]string, error) {
+			pod, ok := obj.(*v1.Pod)
+			if !ok || pod.Spec.HostNetwork {
+				return nil, nil
+			}
+			ips := make([]string, 0, len(pod.Status.PodIPs))
+			for _, ip := range pod.Status.PodIPs {
+				ips = append(ips, ip.IP)
+			}
+			return ips, nil
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	podInformerFactory.Start(stop)
+	nsInformerFactory.Start(stop)
+	if !cache.WaitForCacheSync(stop, i.pods.HasSynced, i.namespaces.HasSynced) {
+		return nil, fmt.Errorf("failed to sync pod and namespace caches")
+	}
+	return i, nil
+}
+
+// IsIntercepted returns whether the traffic of the pod is intercepted by the tc programs
+func (i *Interception) IsIntercepted(pod *v1.Pod) bool {
+	if pod.Spec.HostNetwork || isIgnored(&pod.ObjectMeta) || isExcluded(pod.Namespace) {
+		return false
+	}
+	if enabled, ok := isInjectionEnabled(pod.Annotations); ok {
+		return enabled
+	}
+
+	// A namespace missing from the cache yet falls back to the default, the pod is reconciled again on its next resync
+	ns := i.getNamespace(pod.Namespace)
+	if ns == nil {
+		return config.InterceptByDefault
+	}
+	if isIgnored(&ns.ObjectMeta) {
+		return false
+	}
+	if enabled, ok := isInjectionEnabled(ns.Annotations); ok {
+		return enabled
+	}
+	return config.InterceptByDefault
+}
+
+// GetPod returns the pod with the given namespace and name, from the cache or the api server
+func (i *Interception) GetPod(namespace, name string) (*v1.Pod, error) {
+	obj, exists, err := i.pods.GetStore().GetByKey(fmt.Sprintf("%s/%s", namespace, name))
+	if err == nil && exists {
+		return obj.(*v1.Pod), nil
+	}
+	// The pod may not be in the cache yet when the CNI plugin is called
+	ctx, cancel := context.WithTimeout(context.Background(), podGetTimeout)
+	defer cancel()
+	return i.client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// GetPodByUID returns the pod of the node with the given uid
+func (i *Interception) GetPodByUID(uid types.UID) (*v1.Pod, bool) {
+	objs, err := i.pods.GetIndexer().ByIndex(podUIDIndex, string(uid))
+	if err != nil || len(objs) == 0 {
+		return nil, false
+	}
+	return objs[0].(*v1.Pod), true
+}
+
+// ListPods returns the pods of the node
+func (i *Interception) ListPods() []*v1.Pod {
+	objs := i.pods.GetStore().List()
+	pods := make([]*v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pods = append(pods, obj.(*v1.Pod))
+	}
+	return pods
+}
+
+// hasRunningPod returns whether a pod of the node with the given ip is running
+func (i *Interception) hasRunningPod(ip string) bool {
+	objs, err := i.pods.GetIndexer().ByIndex(podIPIndex, ip)
+	if err != nil {
+		return false
+	}
+	for _, obj := range objs {
+		if isPodRunning(obj.(*v1.Pod)) {
+			return true
+		}
+	}
+	return false
+}
+
+// listNamespacePods returns the pods of the node in the given namespace
+func (i *Interception) listNamespacePods(namespace string) []*v1.Pod {
+	objs, err := i.pods.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		return nil
+	}
+	pods := make([]*v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pods = append(pods, obj.(*v1.Pod))
+	}
+	return pods
+}
+
+func (i *Interception) getNamespace(name string) *v1.Namespace {
+	obj, exists, err := i.namespaces.GetStore().GetByKey(name)
+	if err != nil || !exists {
+		return nil
+	}
+	return obj.(*v1.Namespace)
+}
+
+// isInjectionEnabled returns the value of the flomesh.io/sidecar-injection annotation, and whether it is set
+func isInjectionEnabled(annotations map[string]string) (enabled bool, ok bool) {
+	value, ok := annotations[constants.SidecarInjectionAnnotation]
+	if !ok {
+		return false, false
+	}
+	switch strings.ToLower(value) {
+	case "enabled", "yes", "true":
+		return true, true
+	case "disabled", "no", "false":
+		return false, true
+	default:
+		log.Warn().Msgf("invalid value %q of annotation %s, expected enabled or disabled", value, constants.SidecarInjectionAnnotation)
+		return false, false
+	}
+}
+
+// isExcluded returns whether the namespace is one of the namespaces excluded from the interception
+func isExcluded(namespace string) bool {
+	for _, ns := range config.ExcludeNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnored returns whether the flomesh.io/ignore label, or annotation, is set to true
+func isIgnored(meta *metav1.ObjectMeta) bool {
+	return strings.EqualFold(meta.Labels[constants.IgnoreLabel], "true") ||
+		strings.EqualFold(meta.Annotations[constants.IgnoreLabel], "true")
+}
@@ -2,34 +2,53 @@ package podwatcher
 
 import (
 	"fmt"
-	"os"
 
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/cniserver"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/helpers"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
 )
 
-func runLocalPodController(client kubernetes.Interface, stop chan struct{}) error {
+type localPodController struct {
+	interception *Interception
+	reconciler   *reconciler
+}
+
+func runLocalPodController(interception *Interception, server cniserver.Server, ecnetNamespace string, stop chan struct{}) error {
 	var err error
 
 	if err = helpers.InitLoadPinnedMap(); err != nil {
 		return fmt.Errorf("failed to load ebpf maps: %v", err)
 	}
 
-	w := newWatcher(createLocalPodController(client))
+	go helpers.RunNatSweeper(interception.hasRunningPod, stop)
+
+	c := &localPodController{
+		interception: interception,
+		reconciler:   newReconciler(interception, server),
+	}
+	w := newWatcher(createLocalPodController(c))
 
 	if err = w.start(); err != nil {
 		return fmt.Errorf("start watcher failed: %v", err)
 	}
+	go c.reconciler.run(stop)
 
 	log.Info().Msg("Pod watcher Ready")
 	if err = helpers.AttachProgs(); err != nil {
 		return fmt.Errorf("failed to attach ebpf programs: %v", err)
 	}
 	<-stop
-	w.shutdown()
 
+	// On a rollout, the pinned maps and the attached programs are handed over to the next instance,
+	// which adopts them, so that the established connections keep their original destination
+	if !isUninstalling(interception.client, ecnetNamespace) {
+		log.Info().Msg("Pod watcher Down, ebpf maps and programs handed over")
+		return nil
+	}
+	server.CleanUp()
 	if err = helpers.UnLoadProgs(); err != nil {
 		return fmt.Errorf("unload failed: %v", err)
 	}
@@ -37,52 +56,55 @@ func runLocalPodController(client kubernetes.Interface, stop chan struct{}) erro
 	return nil
 }
 
-func createLocalPodController(client kubernetes.Interface) watcher {
-	localName, err := os.Hostname()
-	if err != nil {
-		panic(err)
-	}
+func createLocalPodController(c *localPodController) watcher {
 	return watcher{
-		Client:          client,
-		CurrentNodeName: localName,
-		OnAddFunc:       addFunc,
-		OnUpdateFunc:    updateFunc,
-		OnDeleteFunc:    deleteFunc,
+		Interception:          c.interception,
+		OnAddFunc:             c.addFunc,
+		OnUpdateFunc:          c.updateFunc,
+		OnDeleteFunc:          c.deleteFunc,
+		OnNamespaceUpdateFunc: c.namespaceUpdateFunc,
 	}
 }
 
-func isInjectedSidecar(_ *v1.Pod) bool {
-	return true
-}
-
-func addFunc(obj interface{}) {
+func (c *localPodController) addFunc(obj interface{}) {
 	pod, ok := obj.(*v1.Pod)
 	if !ok || len(pod.Status.PodIP) == 0 {
 		return
 	}
-	if !isInjectedSidecar(pod) {
-		return
-	}
 	log.Debug().Msgf("got pod updated %s/%s", pod.Namespace, pod.Name)
+	c.reconciler.enqueue(pod)
+}
+
+// updateFunc is also called on every resync of the informer, which repairs the drift of the tc programs
+func (c *localPodController) updateFunc(_, cur interface{}) {
+	c.addFunc(cur)
 }
 
-func updateFunc(old, cur interface{}) {
-	oldPod, ok := old.(*v1.Pod)
+func (c *localPodController) deleteFunc(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*v1.Pod); ok {
+		log.Debug().Msgf("got pod delete %s/%s", pod.Namespace, pod.Name)
+		c.reconciler.server.ForgetPod(pod.UID)
+	}
+}
+
+// namespaceUpdateFunc resyncs the pods of the namespace when its interception annotation or label changes
+func (c *localPodController) namespaceUpdateFunc(old, cur interface{}) {
+	oldNs, ok := old.(*v1.Namespace)
 	if !ok {
 		return
 	}
-	curPod, ok := cur.(*v1.Pod)
+	curNs, ok := cur.(*v1.Namespace)
 	if !ok {
 		return
 	}
-	if oldPod.Status.PodIP != curPod.Status.PodIP {
-		// only care about ip changes
-		addFunc(cur)
+	if oldNs.Annotations[constants.SidecarInjectionAnnotation] == curNs.Annotations[constants.SidecarInjectionAnnotation] &&
+		isIgnored(&oldNs.ObjectMeta) == isIgnored(&curNs.ObjectMeta) {
+		return
 	}
-}
-
-func deleteFunc(obj interface{}) {
-	if pod, ok := obj.(*v1.Pod); ok {
-		log.Debug().Msgf("got pod delete %s/%s", pod.Namespace, pod.Name)
+	for _, pod := range c.interception.listNamespacePods(curNs.Name) {
+		c.reconciler.enqueue(pod)
 	}
 }
@@ -0,0 +1,90 @@
+package podwatcher
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/cniserver"
+)
+
+const (
+	// maxSyncRetries defines how many times the sync of a pod is retried before waiting for the next resync
+	maxSyncRetries = 5
+)
+
+// reconciler keeps the tc programs attached to the intercepted pods of the node, and only to them.
+// It is fed by the pod informer, whose periodic resync repairs the drift continuously.
+type reconciler struct {
+	interception *Interception
+	server       cniserver.Server
+	queue        workqueue.RateLimitingInterface
+}
+
+func newReconciler(interception *Interception, server cniserver.Server) *reconciler {
+	return &reconciler{
+		interception: interception,
+		server:       server,
+		queue:        workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+}
+
+// enqueue schedules the sync of a pod
+func (r *reconciler) enqueue(pod *v1.Pod) {
+	key, err := cache.MetaNamespaceKeyFunc(pod)
+	if err != nil {
+		return
+	}
+	r.queue.Add(key)
+}
+
+// run processes the queued pods until stop is closed
+func (r *reconciler) run(stop <-chan struct{}) {
+	go wait.Until(func() {
+		for r.processNextItem() {
+		}
+	}, time.Second, stop)
+	<-stop
+	r.queue.ShutDown()
+}
+
+func (r *reconciler) processNextItem() bool {
+	item, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(item)
+
+	key := item.(string)
+	obj, exists, err := r.interception.pods.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		// deleted pods are forgotten by the delete handler
+		r.queue.Forget(item)
+		return true
+	}
+
+	pod := obj.(*v1.Pod)
+	if !isPodRunning(pod) {
+		r.queue.Forget(item)
+		return true
+	}
+	if err = r.server.SyncPod(pod); err != nil {
+		if r.queue.NumRequeues(item) < maxSyncRetries {
+			log.Warn().Msgf("sync tc programs of pod %s error, retrying: %v", key, err)
+			r.queue.AddRateLimited(item)
+			return true
+		}
+		log.Error().Msgf("sync tc programs of pod %s error: %v", key, err)
+	}
+	r.queue.Forget(item)
+	return true
+}
+
+// isPodRunning returns whether the pod has its own netns set up, in which the tc programs can be attached
+func isPodRunning(pod *v1.Pod) bool {
+	return !pod.Spec.HostNetwork && len(pod.Status.PodIP) > 0 &&
+		pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed
+}
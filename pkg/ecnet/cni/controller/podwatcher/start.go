@@ -3,13 +3,13 @@ package podwatcher
 import (
 	"fmt"
 
-	"k8s.io/client-go/kubernetes"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/controller/cniserver"
 )
 
 // Run start to run controller to watch
-func Run(client kubernetes.Interface, stop chan struct{}) error {
+func Run(interception *Interception, server cniserver.Server, ecnetNamespace string, stop chan struct{}) error {
 	// run local ip controller
-	if err := runLocalPodController(client, stop); err != nil {
+	if err := runLocalPodController(interception, server, ecnetNamespace, stop); err != nil {
 		return fmt.Errorf("run local ip controller error: %v", err)
 	}
 
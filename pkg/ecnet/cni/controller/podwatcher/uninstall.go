@@ -0,0 +1,36 @@
+package podwatcher
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+)
+
+const (
+	uninstallCheckTimeout = 5 * time.Second
+)
+
+// isUninstalling returns whether ecnet-bridge stops because its DaemonSet is deleted, rather than for a rollout.
+// The DaemonSet being gone, or forbidden with the RBAC of ecnet-bridge deleted along with it, or unauthorized with
+// the ServiceAccount of ecnet-bridge deleted first by helm uninstall, is taken as an uninstall.
+// Any other error keeps the datapath for the next instance, a transient api server failure must not tear it down.
+func isUninstalling(client kubernetes.Interface, namespace string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), uninstallCheckTimeout)
+	defer cancel()
+
+	ds, err := client.AppsV1().DaemonSets(namespace).Get(ctx, constants.ECNETBridgeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) {
+		log.Info().Msgf("DaemonSet %s/%s is gone, cleaning up: %v", namespace, constants.ECNETBridgeName, err)
+		return true
+	}
+	if err != nil {
+		log.Warn().Msgf("failed to get DaemonSet %s/%s, keeping the datapath for the next instance: %v", namespace, constants.ECNETBridgeName, err)
+		return false
+	}
+	return ds.DeletionTimestamp != nil
+}
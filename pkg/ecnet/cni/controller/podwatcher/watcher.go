@@ -1,58 +1,38 @@
 package podwatcher
 
 import (
-	"time"
-
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
-	kubeinformer "k8s.io/client-go/informers"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-
-	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
 )
 
 type watcher struct {
-	Client          kubernetes.Interface
-	CurrentNodeName string
-	OnAddFunc       func(obj interface{})
-	OnUpdateFunc    func(oldObj, newObj interface{})
-	OnDeleteFunc    func(obj interface{})
-	Stop            chan struct{}
+	Interception          *Interception
+	OnAddFunc             func(obj interface{})
+	OnUpdateFunc          func(oldObj, newObj interface{})
+	OnDeleteFunc          func(obj interface{})
+	OnNamespaceUpdateFunc func(oldObj, newObj interface{})
 }
 
+// start registers the handlers on the informers of Interception, which are stopped along with it
 func (w *watcher) start() error {
-	selectByNode := ""
-	if !config.IsKind {
-		selectByNode = fields.OneTermEqualSelector("spec.nodeName", w.CurrentNodeName).String()
-	}
-	kubeInformerFactory := kubeinformer.NewFilteredSharedInformerFactory(
-		w.Client, 30*time.Second, metav1.NamespaceAll,
-		func(o *metav1.ListOptions) {
-			o.FieldSelector = selectByNode
-		},
-	)
-
-	_, _ = kubeInformerFactory.Core().V1().Pods().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	if _, err := w.Interception.pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    w.OnAddFunc,
 		UpdateFunc: w.OnUpdateFunc,
 		DeleteFunc: w.OnDeleteFunc,
+	}); err != nil {
+		return err
+	}
+	_, err := w.Interception.namespaces.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: w.OnNamespaceUpdateFunc,
 	})
-	kubeInformerFactory.Start(w.Stop)
-	return nil
-}
-
-func (w *watcher) shutdown() {
-	close(w.Stop)
+	return err
 }
 
 func newWatcher(watch watcher) *watcher {
 	return &watcher{
-		Client:          watch.Client,
-		CurrentNodeName: watch.CurrentNodeName,
-		OnAddFunc:       watch.OnAddFunc,
-		OnUpdateFunc:    watch.OnUpdateFunc,
-		OnDeleteFunc:    watch.OnDeleteFunc,
-		Stop:            make(chan struct{}),
+		Interception:          watch.Interception,
+		OnAddFunc:             watch.OnAddFunc,
+		OnUpdateFunc:          watch.OnUpdateFunc,
+		OnDeleteFunc:          watch.OnDeleteFunc,
+		OnNamespaceUpdateFunc: watch.OnNamespaceUpdateFunc,
 	}
 }
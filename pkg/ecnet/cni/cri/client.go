@@ -0,0 +1,150 @@
+package cri
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+)
+
+const (
+	// podUIDLabel is the label kubelet sets on the pod sandboxes
+	podUIDLabel = "io.kubernetes.pod.uid"
+
+	requestTimeout = 5 * time.Second
+)
+
+var (
+	// defaultEndpoints are the sockets of containerd and CRI-O, relative to the host /var/run
+	defaultEndpoints = []string{
+		"containerd/containerd.sock",
+		"crio/crio.sock",
+		"cri-dockerd.sock",
+	}
+)
+
+// Client resolves the netns of the pods through the container runtime
+type Client struct {
+	runtime RuntimeService
+	conn    *grpc.ClientConn
+}
+
+// NewClient connects to the CRI endpoint, the well known sockets under config.HostVarRun are tried when it is empty
+func NewClient(endpoint string) (*Client, error) {
+	endpoints := []string{endpoint}
+	if len(endpoint) == 0 {
+		endpoints = nil
+		for _, e := range defaultEndpoints {
+			endpoints = append(endpoints, path.Join(config.HostVarRun, e))
+		}
+	}
+
+	for _, e := range endpoints {
+		if _, err := os.Stat(e); err != nil {
+			continue
+		}
+		conn, err := grpc.Dial("unix://"+e, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("dial cri endpoint %s error: %v", e, err)
+		}
+		c, err := NewClientWithRuntimeService(runtimeapi.NewRuntimeServiceClient(conn))
+		if err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("cri endpoint %s: %v", e, err)
+		}
+		c.conn = conn
+		return c, nil
+	}
+	return nil, fmt.Errorf("no cri endpoint found in %v", endpoints)
+}
+
+// NewClientWithRuntimeService returns a Client using the given runtime service
+func NewClientWithRuntimeService(runtime RuntimeService) (*Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	version, err := runtime.Version(ctx, &runtimeapi.VersionRequest{})
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msgf("connected to container runtime %s %s", version.RuntimeName, version.RuntimeVersion)
+	return &Client{runtime: runtime}, nil
+}
+
+// Close closes the connection to the runtime
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// GetPodNetns returns the netns path of the ready sandbox of the pod, as seen from ecnet-bridge
+func (c *Client) GetPodNetns(uid types.UID) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	sandboxes, err := c.runtime.ListPodSandbox(ctx, &runtimeapi.ListPodSandboxRequest{
+		Filter: &runtimeapi.PodSandboxFilter{
+			State:         &runtimeapi.PodSandboxStateValue{State: runtimeapi.PodSandboxState_SANDBOX_READY},
+			LabelSelector: map[string]string{podUIDLabel: string(uid)},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(sandboxes.Items) == 0 {
+		return "", fmt.Errorf("no ready sandbox found for pod %s", uid)
+	}
+
+	status, err := c.runtime.PodSandboxStatus(ctx, &runtimeapi.PodSandboxStatusRequest{
+		PodSandboxId: sandboxes.Items[0].Id,
+		Verbose:      true,
+	})
+	if err != nil {
+		return "", err
+	}
+	return getSandboxNetns(status.Info)
+}
+
+// getSandboxNetns returns the netns path from the verbose info of a sandbox: the path of its network namespace,
+// or the one of its process when the runtime does not manage the namespace as a file
+func getSandboxNetns(info map[string]string) (string, error) {
+	raw, ok := info["info"]
+	if !ok {
+		return "", fmt.Errorf("no verbose info reported by the runtime")
+	}
+	var sandbox sandboxInfo
+	if err := json.Unmarshal([]byte(raw), &sandbox); err != nil {
+		return "", err
+	}
+
+	for _, ns := range sandbox.RuntimeSpec.Linux.Namespaces {
+		if ns.Type == "network" && len(ns.Path) > 0 {
+			return hostPath(ns.Path), nil
+		}
+	}
+	if sandbox.Pid > 0 {
+		return fmt.Sprintf("%s/%d/ns/net", config.HostProc, sandbox.Pid), nil
+	}
+	return "", fmt.Errorf("no network namespace reported by the runtime")
+}
+
+// hostPath returns the path of a host file as seen from ecnet-bridge, the netns files are usually under /var/run
+func hostPath(p string) string {
+	for _, varRun := range []string{"/var/run/", "/run/"} {
+		if strings.HasPrefix(p, varRun) {
+			return path.Join(config.HostVarRun, strings.TrimPrefix(p, varRun))
+		}
+	}
+	return path.Join("/host", p)
+}
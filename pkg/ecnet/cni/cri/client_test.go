@@ -0,0 +1,163 @@
+package cri
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/config"
+)
+
+// fakeRuntimeService is a RuntimeService serving the given sandboxes and their verbose info
+type fakeRuntimeService struct {
+	versionErr error
+	listErr    error
+	statusErr  error
+	// sandboxes are the ready sandbox ids keyed on the pod uid
+	sandboxes map[string][]string
+	// infos are the verbose infos keyed on the sandbox id
+	infos map[string]map[string]string
+}
+
+func (f *fakeRuntimeService) Version(_ context.Context, _ *runtimeapi.VersionRequest, _ ...grpc.CallOption) (*runtimeapi.VersionResponse, error) {
+	if f.versionErr != nil {
+		return nil, f.versionErr
+	}
+	return &runtimeapi.VersionResponse{RuntimeName: "fake", RuntimeVersion: "v1"}, nil
+}
+
+func (f *fakeRuntimeService) ListPodSandbox(_ context.Context, in *runtimeapi.ListPodSandboxRequest, _ ...grpc.CallOption) (*runtimeapi.ListPodSandboxResponse, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	resp := &runtimeapi.ListPodSandboxResponse{}
+	if in.GetFilter().GetState().GetState() != runtimeapi.PodSandboxState_SANDBOX_READY {
+		return resp, nil
+	}
+	for _, id := range f.sandboxes[in.GetFilter().GetLabelSelector()[podUIDLabel]] {
+		resp.Items = append(resp.Items, &runtimeapi.PodSandbox{Id: id, State: runtimeapi.PodSandboxState_SANDBOX_READY})
+	}
+	return resp, nil
+}
+
+func (f *fakeRuntimeService) PodSandboxStatus(_ context.Context, in *runtimeapi.PodSandboxStatusRequest, _ ...grpc.CallOption) (*runtimeapi.PodSandboxStatusResponse, error) {
+	if f.statusErr != nil {
+		return nil, f.statusErr
+	}
+	resp := &runtimeapi.PodSandboxStatusResponse{Status: &runtimeapi.PodSandboxStatus{Id: in.PodSandboxId}}
+	if in.Verbose {
+		resp.Info = f.infos[in.PodSandboxId]
+	}
+	return resp, nil
+}
+
+func TestNewClientWithRuntimeService(t *testing.T) {
+	if _, err := NewClientWithRuntimeService(&fakeRuntimeService{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewClientWithRuntimeService(&fakeRuntimeService{versionErr: errors.New("unavailable")}); err == nil {
+		t.Error("expected an error when the runtime version cannot be got")
+	}
+}
+
+func TestGetPodNetns(t *testing.T) {
+	config.HostVarRun = "/host/var/run"
+	config.HostProc = "/host/proc"
+
+	const uid = types.UID("pod-uid")
+	withInfo := func(info string) *fakeRuntimeService {
+		return &fakeRuntimeService{
+			sandboxes: map[string][]string{string(uid): {"sandbox"}},
+			infos:     map[string]map[string]string{"sandbox": {"info": info}},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		runtime       *fakeRuntimeService
+		expectedNetns string
+		expectErr     bool
+	}{
+		{
+			name:          "netns under /var/run",
+			runtime:       withInfo(`{"runtimeSpec":{"linux":{"namespaces":[{"type":"pid"},{"type":"network","path":"/var/run/netns/cni-1"}]}}}`),
+			expectedNetns: "/host/var/run/netns/cni-1",
+		},
+		{
+			name:          "netns under /run",
+			runtime:       withInfo(`{"runtimeSpec":{"linux":{"namespaces":[{"type":"network","path":"/run/netns/cni-1"}]}}}`),
+			expectedNetns: "/host/var/run/netns/cni-1",
+		},
+		{
+			name:          "netns elsewhere on the host",
+			runtime:       withInfo(`{"runtimeSpec":{"linux":{"namespaces":[{"type":"network","path":"/tmp/netns/cni-1"}]}}}`),
+			expectedNetns: "/host/tmp/netns/cni-1",
+		},
+		{
+			name:          "netns of the sandbox process",
+			runtime:       withInfo(`{"pid":42,"runtimeSpec":{"linux":{"namespaces":[{"type":"network"}]}}}`),
+			expectedNetns: "/host/proc/42/ns/net",
+		},
+		{
+			name:      "no network namespace reported",
+			runtime:   withInfo(`{"runtimeSpec":{"linux":{"namespaces":[{"type":"pid"}]}}}`),
+			expectErr: true,
+		},
+		{
+			name:      "malformed verbose info",
+			runtime:   withInfo(`{`),
+			expectErr: true,
+		},
+		{
+			name: "no verbose info",
+			runtime: &fakeRuntimeService{
+				sandboxes: map[string][]string{string(uid): {"sandbox"}},
+			},
+			expectErr: true,
+		},
+		{
+			name:      "no ready sandbox",
+			runtime:   &fakeRuntimeService{sandboxes: map[string][]string{"other-uid": {"sandbox"}}},
+			expectErr: true,
+		},
+		{
+			name:      "listing the sandboxes fails",
+			runtime:   &fakeRuntimeService{listErr: errors.New("unavailable")},
+			expectErr: true,
+		},
+		{
+			name: "getting the sandbox status fails",
+			runtime: &fakeRuntimeService{
+				sandboxes: map[string][]string{string(uid): {"sandbox"}},
+				statusErr: errors.New("unavailable"),
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewClientWithRuntimeService(tc.runtime)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			netns, err := c.GetPodNetns(uid)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got netns %q", netns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if netns != tc.expectedNetns {
+				t.Errorf("expected netns %q, got %q", tc.expectedNetns, netns)
+			}
+		})
+	}
+}
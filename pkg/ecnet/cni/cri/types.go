@@ -0,0 +1,39 @@
+// Package cri resolves the network namespaces of the pods through the CRI API of the container runtime.
+package cri
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
+)
+
+var (
+	log = logger.New("bridge-cri")
+)
+
+// RuntimeService is the part of the CRI runtime service used to resolve the netns of the pods.
+// It is implemented by the grpc client of the runtime, a fake CRI server can be served in its place.
+type RuntimeService interface {
+	// Version returns the runtime name and version
+	Version(ctx context.Context, in *runtimeapi.VersionRequest, opts ...grpc.CallOption) (*runtimeapi.VersionResponse, error)
+	// ListPodSandbox returns the pod sandboxes matching the filter
+	ListPodSandbox(ctx context.Context, in *runtimeapi.ListPodSandboxRequest, opts ...grpc.CallOption) (*runtimeapi.ListPodSandboxResponse, error)
+	// PodSandboxStatus returns the status of a pod sandbox, along with the runtime specific info when verbose
+	PodSandboxStatus(ctx context.Context, in *runtimeapi.PodSandboxStatusRequest, opts ...grpc.CallOption) (*runtimeapi.PodSandboxStatusResponse, error)
+}
+
+// sandboxInfo is the verbose info of a pod sandbox reported by containerd and CRI-O
+type sandboxInfo struct {
+	Pid         int `json:"pid"`
+	RuntimeSpec struct {
+		Linux struct {
+			Namespaces []struct {
+				Type string `json:"type"`
+				Path string `json:"path"`
+			} `json:"namespaces"`
+		} `json:"linux"`
+	} `json:"runtimeSpec"`
+}
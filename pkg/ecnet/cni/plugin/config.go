@@ -31,6 +31,9 @@ type Config struct {
 	LogLevel        string `json:"log_level"`
 	LogUDSAddress   string `json:"log_uds_address"`
 	HostNSEnterExec bool   `json:"hostNSEnterExec"`
+
+	// ExcludeNamespaces are the namespaces whose pods are ignored
+	ExcludeNamespaces []string `json:"exclude_namespaces"`
 }
 
 // parseConfig parses the supplied configuration (and prevResult) from stdin.
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
@@ -15,6 +18,11 @@ import (
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/cni/util"
 )
 
+const (
+	// checkTimeout bounds the time the CNI CHECK waits for ecnet-bridge, not to hang kubelet
+	checkTimeout = 10 * time.Second
+)
+
 // K8sArgs is the valid CNI_ARGS used for Kubernetes
 // The field names need to match exact keys in kubelet args for unmarshalling
 type K8sArgs struct {
@@ -23,9 +31,20 @@ type K8sArgs struct {
 	K8S_POD_NAME               types.UnmarshallableString // nolint: revive, stylecheck
 	K8S_POD_NAMESPACE          types.UnmarshallableString // nolint: revive, stylecheck
 	K8S_POD_INFRA_CONTAINER_ID types.UnmarshallableString // nolint: revive, stylecheck
+	K8S_POD_UID                types.UnmarshallableString // nolint: revive, stylecheck
 }
 
-func ignore(_ *Config, _ *K8sArgs) bool {
+// ignore skips the sandboxes which are not kubernetes pods and the pods of the excluded namespaces,
+// whether another pod is intercepted is decided by ecnet-bridge from its annotations and the ones of its namespace
+func ignore(conf *Config, k8sArgs *K8sArgs) bool {
+	if len(k8sArgs.K8S_POD_NAMESPACE) == 0 || len(k8sArgs.K8S_POD_NAME) == 0 {
+		return true
+	}
+	for _, ns := range conf.ExcludeNamespaces {
+		if ns == string(k8sArgs.K8S_POD_NAMESPACE) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -70,8 +89,40 @@ func CmdAdd(args *skel.CmdArgs) error {
 	return types.PrintResult(result, conf.CNIVersion)
 }
 
-// CmdCheck is the implementation of the cmdCheck interface of CNI plugin
-func CmdCheck(*skel.CmdArgs) error {
+// CmdCheck is the implementation of the cmdCheck interface of CNI plugin,
+// it fails when the tc programs of an intercepted pod are not attached
+func CmdCheck(args *skel.CmdArgs) error {
+	conf, err := parseConfig(args.StdinData)
+	if err != nil {
+		return err
+	}
+	k8sArgs := K8sArgs{}
+	if err = types.LoadArgs(args.Args, &k8sArgs); err != nil {
+		return err
+	}
+	if ignore(conf, &k8sArgs) || !util.Exists(config.CNISock) {
+		return nil
+	}
+
+	httpc := http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", config.CNISock)
+			},
+		},
+		Timeout: checkTimeout,
+	}
+	bs, _ := json.Marshal(args)
+	body := bytes.NewReader(bs)
+	resp, err := httpc.Post("http://ecnet-cni"+config.CNICheckPodURL, "application/json", body)
+	if err != nil {
+		return fmt.Errorf("ecnet-cni cmdCheck failed to post args: %v", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ecnet-cni cmdCheck failed: %s", msg)
+	}
 	return nil
 }
 
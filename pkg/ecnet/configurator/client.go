@@ -12,11 +12,13 @@ import (
 )
 
 // NewConfigurator implements configurator.Configurator and creates the Kubernetes client to manage namespaces.
-func NewConfigurator(informerCollection *informers.InformerCollection, ecnetNamespace, ecnetConfigName string, msgBroker *messaging.Broker) *Client {
+// The remote logging secret is the name of the Secret given to the bridges for the authorization of remote logging.
+func NewConfigurator(informerCollection *informers.InformerCollection, ecnetNamespace, ecnetConfigName, remoteLoggingSecret string, msgBroker *messaging.Broker) *Client {
 	c := &Client{
-		informers:       informerCollection,
-		ecnetNamespace:  ecnetNamespace,
-		ecnetConfigName: ecnetConfigName,
+		informers:           informerCollection,
+		ecnetNamespace:      ecnetNamespace,
+		ecnetConfigName:     ecnetConfigName,
+		remoteLoggingSecret: remoteLoggingSecret,
 	}
 
 	// configure listener
@@ -28,6 +30,14 @@ func NewConfigurator(informerCollection *informers.InformerCollection, ecnetName
 
 	informerCollection.AddEventHandler(informers.InformerKeyEcnetConfig, k8s.GetEventHandlerFuncs(nil, ecnetConfigEventTypes, msgBroker))
 
+	pluginEventTypes := k8s.EventTypes{
+		Add:    announcements.PluginAdded,
+		Update: announcements.PluginUpdated,
+		Delete: announcements.PluginDeleted,
+	}
+
+	informerCollection.AddEventHandler(informers.InformerKeyPlugin, k8s.GetEventHandlerFuncs(nil, pluginEventTypes, msgBroker))
+
 	return c
 }
 
@@ -35,6 +45,17 @@ func (c *Client) getEcnetConfigCacheKey() string {
 	return fmt.Sprintf("%s/%s", c.ecnetNamespace, c.ecnetConfigName)
 }
 
+// Returns the Plugins in the namespace of ECNET controller
+func (c *Client) getPlugins() []*configv1alpha1.Plugin {
+	var plugins []*configv1alpha1.Plugin
+
+	for _, pluginIface := range c.informers.List(informers.InformerKeyPlugin) {
+		plugin := pluginIface.(*configv1alpha1.Plugin)
+		plugins = append(plugins, plugin)
+	}
+	return plugins
+}
+
 // Returns the current EcnetConfig
 func (c *Client) getEcnetConfig() configv1alpha1.EcnetConfig {
 	var ecnetConfig configv1alpha1.EcnetConfig
@@ -2,13 +2,16 @@ package configurator
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	configv1alpha1 "github.com/flomesh-io/ErieCanal/pkg/ecnet/apis/config/v1alpha1"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/errcode"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service/policy"
 )
 
@@ -57,6 +60,64 @@ func (c *Client) GetLocalDNSProxySecondaryUpstream() string {
 	return c.getEcnetConfig().Spec.Sidecar.LocalDNSProxy.SecondaryUpstreamDNSServerIPAddr
 }
 
+// GetLocalDNSProxyTTL returns the time to live in seconds of the records answered by local DNS Proxy
+func (c *Client) GetLocalDNSProxyTTL() uint32 {
+	if ttl := c.getEcnetConfig().Spec.Sidecar.LocalDNSProxy.TTL; ttl > 0 {
+		return ttl
+	}
+	return constants.DefaultLocalDNSProxyTTL
+}
+
+// GetLocalDNSProxyNegativeTTL returns the time in seconds local DNS Proxy caches the names that do not exist
+func (c *Client) GetLocalDNSProxyNegativeTTL() uint32 {
+	if ttl := c.getEcnetConfig().Spec.Sidecar.LocalDNSProxy.NegativeTTL; ttl > 0 {
+		return ttl
+	}
+	return constants.DefaultLocalDNSProxyNegativeTTL
+}
+
+// GetClusterSetVIPCIDR returns the CIDR the virtual IPs of the ClusterSetIP imports are allocated from
+func (c *Client) GetClusterSetVIPCIDR() string {
+	return c.getEcnetConfig().Spec.ClusterSet.VIPCIDR
+}
+
+// GetBridgeProxyPort returns the port the bridge proxy listens on for the tcp traffic of the imported services
+func (c *Client) GetBridgeProxyPort() uint16 {
+	if port := c.getEcnetConfig().Spec.Bridge.ProxyPort; port > 0 {
+		return port
+	}
+	return constants.DefaultBridgeProxyPort
+}
+
+// GetBridgeUDPProxyPort returns the port the bridge proxy listens on for the udp traffic of the imported services
+func (c *Client) GetBridgeUDPProxyPort() uint16 {
+	if port := c.getEcnetConfig().Spec.Bridge.UDPProxyPort; port > 0 {
+		return port
+	}
+	return constants.DefaultBridgeUDPProxyPort
+}
+
+// GetBridgeDNSProxyPort returns the port the bridge DNS proxy listens on
+func (c *Client) GetBridgeDNSProxyPort() uint16 {
+	if port := c.getEcnetConfig().Spec.Bridge.DNSProxyPort; port > 0 {
+		return port
+	}
+	return constants.DefaultBridgeDNSProxyPort
+}
+
+// GetBridgeDNSCapturePort returns the destination port of the DNS queries redirected to the bridge DNS proxy
+func (c *Client) GetBridgeDNSCapturePort() uint16 {
+	if port := c.getEcnetConfig().Spec.Bridge.DNSCapturePort; port > 0 {
+		return port
+	}
+	return constants.DefaultBridgeDNSCapturePort
+}
+
+// IsBridgeKernelTracingEnabled returns whether the ebpf programs print their debug traces
+func (c *Client) IsBridgeKernelTracingEnabled() bool {
+	return c.getEcnetConfig().Spec.Bridge.KernelTracing
+}
+
 // GetSidecarLogLevel returns the sidecar log level
 func (c *Client) GetSidecarLogLevel() string {
 	logLevel := c.getEcnetConfig().Spec.Sidecar.LogLevel
@@ -110,7 +171,120 @@ func (c *Client) GetConfigResyncInterval() time.Duration {
 	return duration
 }
 
-// GetGlobalPluginChains returns plugin chains
+// IsTracingEnabled returns whether tracing is enabled
+func (c *Client) IsTracingEnabled() bool {
+	return c.getEcnetConfig().Spec.Observability.Tracing.Enable
+}
+
+// GetTracingHost is the host to which we send tracing spans
+func (c *Client) GetTracingHost() string {
+	tracingAddress := c.getEcnetConfig().Spec.Observability.Tracing.Address
+	if tracingAddress != "" {
+		return tracingAddress
+	}
+	return fmt.Sprintf("jaeger.%s.svc.%s", c.GetEcnetNamespace(), k8s.GetTrustDomain())
+}
+
+// GetTracingPort returns the tracing listener port
+func (c *Client) GetTracingPort() uint32 {
+	tracingPort := c.getEcnetConfig().Spec.Observability.Tracing.Port
+	if tracingPort != 0 {
+		return uint32(tracingPort)
+	}
+	return constants.DefaultTracingPort
+}
+
+// GetTracingEndpoint returns the listener's collector endpoint
+func (c *Client) GetTracingEndpoint() string {
+	tracingEndpoint := c.getEcnetConfig().Spec.Observability.Tracing.Endpoint
+	if tracingEndpoint != "" {
+		return tracingEndpoint
+	}
+	return constants.DefaultTracingEndpoint
+}
+
+// GetTracingSampledFraction returns the sampled fraction
+func (c *Client) GetTracingSampledFraction() float32 {
+	sampledFraction := c.getEcnetConfig().Spec.Observability.Tracing.SampledFraction
+	if sampledFraction != nil && len(*sampledFraction) > 0 {
+		if v, e := strconv.ParseFloat(*sampledFraction, 32); e == nil && v >= 0 && v <= 1 {
+			return float32(v)
+		}
+		log.Warn().Msgf("Invalid tracing sampled fraction: %s", *sampledFraction)
+	}
+	return 1
+}
+
+// GetTracingPropagation returns the trace context formats propagated across clusters
+func (c *Client) GetTracingPropagation() string {
+	propagation := c.getEcnetConfig().Spec.Observability.Tracing.Propagation
+	if propagation != "" {
+		return propagation
+	}
+	return constants.DefaultTracingPropagation
+}
+
+// IsRemoteLoggingEnabled returns whether remote logging is enabled
+func (c *Client) IsRemoteLoggingEnabled() bool {
+	return c.getEcnetConfig().Spec.Observability.RemoteLogging.Enable
+}
+
+// GetRemoteLoggingHost is the host to which we send logging spans
+func (c *Client) GetRemoteLoggingHost() string {
+	return c.getEcnetConfig().Spec.Observability.RemoteLogging.Address
+}
+
+// GetRemoteLoggingPort returns the remote logging listener port
+func (c *Client) GetRemoteLoggingPort() uint32 {
+	remoteLoggingPort := c.getEcnetConfig().Spec.Observability.RemoteLogging.Port
+	if remoteLoggingPort != 0 {
+		return uint32(remoteLoggingPort)
+	}
+	return constants.DefaultRemoteLoggingPort
+}
+
+// GetRemoteLoggingEndpoint returns the collector endpoint
+func (c *Client) GetRemoteLoggingEndpoint() string {
+	remoteLoggingEndpoint := c.getEcnetConfig().Spec.Observability.RemoteLogging.Endpoint
+	if remoteLoggingEndpoint != "" {
+		return remoteLoggingEndpoint
+	}
+	return constants.DefaultRemoteLoggingEndpoint
+}
+
+// GetRemoteLoggingAuthorizationKey returns the key of the authorization of remote logging in the Secret given to the bridges.
+// The authorization is read by the bridges from their environment, only the Secret passed to ecnet-controller with
+// --remote-logging-secret is given to them, any other one is rejected.
+func (c *Client) GetRemoteLoggingAuthorizationKey() (string, error) {
+	authorizationSecret := c.getEcnetConfig().Spec.Observability.RemoteLogging.AuthorizationSecret
+	if authorizationSecret == nil || authorizationSecret.Name == "" {
+		return "", nil
+	}
+	if authorizationSecret.Name != c.remoteLoggingSecret {
+		return "", fmt.Errorf("remote logging authorization Secret %s/%s is not the one given to the bridges %q",
+			c.ecnetNamespace, authorizationSecret.Name, c.remoteLoggingSecret)
+	}
+	return authorizationSecret.Key, nil
+}
+
+// GetRemoteLoggingSampledFraction returns the sampled fraction
+func (c *Client) GetRemoteLoggingSampledFraction() float32 {
+	sampledFraction := c.getEcnetConfig().Spec.Observability.RemoteLogging.SampledFraction
+	if sampledFraction != nil && len(*sampledFraction) > 0 {
+		if v, e := strconv.ParseFloat(*sampledFraction, 32); e == nil && v >= 0 && v <= 1 {
+			return float32(v)
+		}
+		log.Warn().Msgf("Invalid remote logging sampled fraction: %s", *sampledFraction)
+	}
+	return 1
+}
+
+// GetRemoteLoggingFields returns the fields of the access log to be shipped
+func (c *Client) GetRemoteLoggingFields() []string {
+	return c.getEcnetConfig().Spec.Observability.RemoteLogging.Fields
+}
+
+// GetGlobalPluginChains returns plugin chains, including the custom plugins
 func (c *Client) GetGlobalPluginChains() map[string][]policy.Plugin {
 	pluginChainMap := make(map[string][]policy.Plugin)
 	pluginChainSpec := c.getEcnetConfig().Spec.PluginChains
@@ -167,5 +341,22 @@ func (c *Client) GetGlobalPluginChains() map[string][]policy.Plugin {
 	pluginChainMap["inbound-http"] = inboundHTTPChains
 	pluginChainMap["outbound-tcp"] = outboundTCPChains
 	pluginChainMap["outbound-http"] = outboundHTTPChains
+
+	for _, plugin := range c.getPlugins() {
+		if plugin.Spec.Disable {
+			continue
+		}
+		chain, exists := pluginChainMap[plugin.Spec.Chain]
+		if !exists {
+			log.Warn().Msgf("Plugin %s/%s mounted to unknown chain %s, ignored", plugin.Namespace, plugin.Name, plugin.Spec.Chain)
+			continue
+		}
+		pluginChainMap[plugin.Spec.Chain] = append(chain, policy.Plugin{
+			Name:      plugin.Name,
+			Namespace: plugin.Namespace,
+			Priority:  plugin.Spec.Priority,
+			Script:    plugin.Spec.Script,
+		})
+	}
 	return pluginChainMap
 }
@@ -16,9 +16,10 @@ var (
 
 // Client is the type used to represent the Kubernetes Client for the config.flomesh.io API group
 type Client struct {
-	ecnetNamespace  string
-	informers       *informers.InformerCollection
-	ecnetConfigName string
+	ecnetNamespace      string
+	informers           *informers.InformerCollection
+	ecnetConfigName     string
+	remoteLoggingSecret string
 }
 
 // Configurator is the controller interface for K8s namespaces
@@ -41,6 +42,12 @@ type Configurator interface {
 	// GetLocalDNSProxySecondaryUpstream returns the secondary upstream DNS server for local DNS Proxy
 	GetLocalDNSProxySecondaryUpstream() string
 
+	// GetLocalDNSProxyTTL returns the time to live in seconds of the records answered by local DNS Proxy
+	GetLocalDNSProxyTTL() uint32
+
+	// GetLocalDNSProxyNegativeTTL returns the time in seconds local DNS Proxy caches the names that do not exist
+	GetLocalDNSProxyNegativeTTL() uint32
+
 	// GetSidecarLogLevel returns the sidecar log level
 	GetSidecarLogLevel() string
 
@@ -57,6 +64,63 @@ type Configurator interface {
 	// If error or non-parsable value, returns 0 duration
 	GetConfigResyncInterval() time.Duration
 
-	// GetGlobalPluginChains returns plugin chains
+	// GetGlobalPluginChains returns plugin chains, including the custom plugins
 	GetGlobalPluginChains() map[string][]policy.Plugin
+
+	// IsTracingEnabled returns whether tracing is enabled
+	IsTracingEnabled() bool
+
+	// GetTracingHost is the host to which we send tracing spans
+	GetTracingHost() string
+
+	// GetTracingPort returns the tracing listener port
+	GetTracingPort() uint32
+
+	// GetTracingEndpoint returns the collector endpoint
+	GetTracingEndpoint() string
+
+	// GetTracingSampledFraction returns the sampled fraction
+	GetTracingSampledFraction() float32
+
+	// GetTracingPropagation returns the trace context formats propagated across clusters
+	GetTracingPropagation() string
+
+	// IsRemoteLoggingEnabled returns whether remote logging is enabled
+	IsRemoteLoggingEnabled() bool
+
+	// GetRemoteLoggingHost is the host to which we send logging spans
+	GetRemoteLoggingHost() string
+
+	// GetRemoteLoggingPort returns the remote logging listener port
+	GetRemoteLoggingPort() uint32
+
+	// GetRemoteLoggingEndpoint returns the collector endpoint
+	GetRemoteLoggingEndpoint() string
+
+	// GetRemoteLoggingAuthorizationKey returns the key of the authorization of remote logging in the Secret given to the bridges
+	GetRemoteLoggingAuthorizationKey() (string, error)
+
+	// GetRemoteLoggingSampledFraction returns the sampled fraction
+	GetRemoteLoggingSampledFraction() float32
+
+	// GetRemoteLoggingFields returns the fields of the access log to be shipped
+	GetRemoteLoggingFields() []string
+
+	// GetClusterSetVIPCIDR returns the CIDR the virtual IPs of the ClusterSetIP imports are allocated from
+	GetClusterSetVIPCIDR() string
+
+	// GetBridgeProxyPort returns the port the bridge proxy listens on for the tcp traffic of the imported services
+	GetBridgeProxyPort() uint16
+
+	// GetBridgeUDPProxyPort returns the port the bridge proxy listens on for the udp traffic of the imported services
+	GetBridgeUDPProxyPort() uint16
+
+	// GetBridgeDNSProxyPort returns the port the bridge DNS proxy listens on
+	GetBridgeDNSProxyPort() uint16
+
+	// GetBridgeDNSCapturePort returns the destination port of the DNS queries redirected to the bridge DNS proxy
+	GetBridgeDNSCapturePort() uint16
+
+	// IsBridgeKernelTracingEnabled returns whether the ebpf programs print their debug traces
+	IsBridgeKernelTracingEnabled() bool
 }
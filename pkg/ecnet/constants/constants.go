@@ -8,15 +8,63 @@ const (
 	// DefaultECNETLogLevel is the default ECNET log level if none is specified
 	DefaultECNETLogLevel = "info"
 
+	// DefaultTracingEndpoint is the default endpoint route.
+	DefaultTracingEndpoint = "/api/v2/spans"
+
+	// DefaultTracingPort is the tracing listener port.
+	DefaultTracingPort = uint32(9411)
+
+	// DefaultTracingPropagation is the default trace context formats propagated across clusters.
+	DefaultTracingPropagation = "b3+w3c"
+
+	// DefaultRateLimitResponseStatusCode is the default HTTP status code of the response to a rate limited request.
+	DefaultRateLimitResponseStatusCode = 429
+
+	// DefaultGRPCRetryBackoffBaseInterval is the default base interval in seconds of the backoff between gRPC retries.
+	DefaultGRPCRetryBackoffBaseInterval = 0.025
+
+	// DefaultLocalDNSProxyTTL is the default time to live in seconds of the records answered by local DNS Proxy.
+	DefaultLocalDNSProxyTTL = uint32(600)
+
+	// DefaultLocalDNSProxyNegativeTTL is the default time in seconds local DNS Proxy caches the names that do not exist.
+	DefaultLocalDNSProxyNegativeTTL = uint32(30)
+
+	// DefaultBridgeProxyPort is the default port the bridge proxy listens on for the tcp traffic of the imported services.
+	DefaultBridgeProxyPort = uint16(15001)
+
+	// DefaultBridgeUDPProxyPort is the default port the bridge proxy listens on for the udp traffic of the imported services.
+	DefaultBridgeUDPProxyPort = uint16(15002)
+
+	// DefaultBridgeDNSProxyPort is the default port the bridge DNS proxy listens on.
+	DefaultBridgeDNSProxyPort = uint16(15053)
+
+	// DefaultBridgeDNSCapturePort is the default destination port of the DNS queries redirected to the bridge DNS proxy.
+	DefaultBridgeDNSCapturePort = uint16(53)
+
+	// DefaultRemoteLoggingPort is the default remote logging service port.
+	DefaultRemoteLoggingPort = uint32(8123)
+
+	// DefaultRemoteLoggingEndpoint is the default remote logging endpoint route.
+	DefaultRemoteLoggingEndpoint = "/?query=insert%20into%20log(message)%20format%20JSONAsString"
+
 	// ECNETHTTPServerPort is the port on which ecnet-controller and ecnet-injector serve HTTP requests for metrics, health probes etc.
 	ECNETHTTPServerPort = 9091
 
+	// ECNETBridgeHTTPServerPort is the port on which ecnet-bridge serves HTTP requests for metrics, version etc.
+	ECNETBridgeHTTPServerPort = 9096
+
+	// ECNETBridgeStatusServerPort is the port on which ecnet-bridge serves the attachment status of the pods on the loopback interface.
+	ECNETBridgeStatusServerPort = 9097
+
 	// ECNETControllerName is the name of the ECNET Controller (formerly ADS service).
 	ECNETControllerName = "ecnet-controller"
 
 	// ECNETBootstrapName is the name of the ECNET Bootstrap.
 	ECNETBootstrapName = "ecnet-bootstrap"
 
+	// ECNETBridgeName is the name of the ECNET Bridge.
+	ECNETBridgeName = "ecnet-bridge"
+
 	// RegexMatchAll is a regex pattern match for all
 	RegexMatchAll = ".*"
 
@@ -43,6 +91,10 @@ const (
 
 	// MetricsAnnotation is the annotation used for enabling/disabling metrics
 	MetricsAnnotation = "flomesh.io/metrics"
+
+	// CodebaseOverlayPathAnnotation is the annotation used to place the files of a codebase overlay
+	// ConfigMap under a sub directory of the base codebase, ex. modules
+	CodebaseOverlayPathAnnotation = "flomesh.io/codebase-overlay-path"
 )
 
 // Labels used by the control plane
@@ -52,6 +104,9 @@ const (
 
 	// AppLabel is the label used to identify the app
 	AppLabel = "app"
+
+	// CodebaseOverlayLabel is the label used to select the ConfigMaps holding codebase overlays
+	CodebaseOverlayLabel = "flomesh.io/codebase-overlay"
 )
 
 // Annotations used for Metrics
@@ -80,6 +135,9 @@ const (
 	// gRPC protocol
 	ProtocolGRPC = "grpc"
 
+	// UDP protocol
+	ProtocolUDP = "udp"
+
 	// ProtocolTCPServerFirst implies TCP based server first protocols
 	// Ex. MySQL, SMTP, PostgreSQL etc. where the server initiates the first
 	// byte in a TCP connection.
@@ -97,8 +155,41 @@ const (
 	// VersionPath is the path at which ECNET controller serves version info
 	VersionPath = "/version"
 
+	// MetricsPath is the path at which ECNET controller serves its metrics
+	MetricsPath = "/metrics"
+
 	// WebhookHealthPath is the path at which the webooks serve health probes
 	WebhookHealthPath = "/healthz"
+
+	// ECNETBridgePodsStatusPath is the path at which ecnet-bridge serves the attachment status of the pods of its node
+	ECNETBridgePodsStatusPath = "/pods"
+)
+
+// Bridge proxy health probes
+const (
+	// ProxyLivenessProbePort is the port on which the bridge proxy serves liveness probes
+	ProxyLivenessProbePort = 15901
+
+	// ProxyReadinessProbePort is the port on which the bridge proxy serves readiness probes
+	ProxyReadinessProbePort = 15902
+
+	// ProxyStartupProbePort is the port on which the bridge proxy serves startup probes
+	ProxyStartupProbePort = 15903
+
+	// ProxyLivenessProbePath is the path at which the bridge proxy serves liveness probes
+	ProxyLivenessProbePath = "/ecnet-liveness-probe"
+
+	// ProxyReadinessProbePath is the path at which the bridge proxy serves readiness probes
+	ProxyReadinessProbePath = "/ecnet-readiness-probe"
+
+	// ProxyStartupProbePath is the path at which the bridge proxy serves startup probes
+	ProxyStartupProbePath = "/ecnet-startup-probe"
+
+	// ProxyStatsPort is the port on which the bridge proxy serves its stats and the manifest of the loaded codebase
+	ProxyStatsPort = 15000
+
+	// ProxyManifestPath is the path at which the bridge proxy serves the manifest of the loaded codebase
+	ProxyManifestPath = "/manifest"
 )
 
 // ECNET HTTP Server Responses
@@ -112,7 +203,7 @@ const (
 
 var (
 	// SupportedProtocolsInMesh is a list of the protocols ECNET supports for in-mesh traffic
-	SupportedProtocolsInMesh = []string{ProtocolTCPServerFirst, ProtocolHTTP, ProtocolTCP, ProtocolGRPC}
+	SupportedProtocolsInMesh = []string{ProtocolTCPServerFirst, ProtocolHTTP, ProtocolTCP, ProtocolGRPC, ProtocolUDP}
 )
 
 const (
@@ -4,6 +4,8 @@ package errcode
 
 import (
 	"fmt"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/metricsstore"
 )
 
 // ErrCode defines the type to represent error codes
@@ -38,6 +40,27 @@ const (
 	ErrEcnetConfigMarshaling
 )
 
+// Range 4200-4250 reserved for errors related to flomesh.io multicluster resources
+const (
+	// ErrInvalidClusterSetVIPCIDR indicates the CIDR configured for the ClusterSetIP imports is not a valid IPv4 CIDR
+	ErrInvalidClusterSetVIPCIDR ErrCode = iota + 4200
+
+	// ErrAllocatingClusterSetIP indicates no virtual IP is left in the CIDR configured for the ClusterSetIP imports
+	ErrAllocatingClusterSetIP
+
+	// ErrUpdatingServiceImport indicates failed to update a ServiceImport resource
+	ErrUpdatingServiceImport
+)
+
+// Range 5000-5050 reserved for errors related to the pipy repo
+const (
+	// ErrInvalidCodebaseOverlay indicates a codebase overlay failed the validation
+	ErrInvalidCodebaseOverlay ErrCode = iota + 5000
+
+	// ErrUploadingCodebase indicates failed to upload the base codebase to the pipy repo
+	ErrUploadingCodebase
+)
+
 // String returns the error code as a string, ex. E1000
 func (e ErrCode) String() string {
 	return fmt.Sprintf("E%d", e)
@@ -46,5 +69,6 @@ func (e ErrCode) String() string {
 // GetErrCodeWithMetric increments the ErrCodeCounter metric for the given error code
 // Returns the error code as a string
 func GetErrCodeWithMetric(e ErrCode) string {
+	metricsstore.DefaultMetricsStore.ErrCodeCounter.WithLabelValues(e.String()).Inc()
 	return e.String()
 }
@@ -26,6 +26,7 @@ import (
 type ConfigV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	EcnetConfigsGetter
+	PluginsGetter
 }
 
 // ConfigV1alpha1Client is used to interact with features provided by the config.flomesh.io group.
@@ -37,6 +38,10 @@ func (c *ConfigV1alpha1Client) EcnetConfigs(namespace string) EcnetConfigInterfa
 	return newEcnetConfigs(c, namespace)
 }
 
+func (c *ConfigV1alpha1Client) Plugins(namespace string) PluginInterface {
+	return newPlugins(c, namespace)
+}
+
 // NewForConfig creates a new ConfigV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -29,6 +29,10 @@ func (c *FakeConfigV1alpha1) EcnetConfigs(namespace string) v1alpha1.EcnetConfig
 	return &FakeEcnetConfigs{c, namespace}
 }
 
+func (c *FakeConfigV1alpha1) Plugins(namespace string) v1alpha1.PluginInterface {
+	return &FakePlugins{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeConfigV1alpha1) RESTClient() rest.Interface {
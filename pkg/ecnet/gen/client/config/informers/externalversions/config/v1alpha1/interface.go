@@ -23,6 +23,8 @@ import (
 type Interface interface {
 	// EcnetConfigs returns a EcnetConfigInformer.
 	EcnetConfigs() EcnetConfigInformer
+	// Plugins returns a PluginInformer.
+	Plugins() PluginInformer
 }
 
 type version struct {
@@ -40,3 +42,8 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) EcnetConfigs() EcnetConfigInformer {
 	return &ecnetConfigInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// Plugins returns a PluginInformer.
+func (v *version) Plugins() PluginInformer {
+	return &pluginInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
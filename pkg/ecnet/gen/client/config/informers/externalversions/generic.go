@@ -52,6 +52,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=config.flomesh.io, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("ecnetconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Config().V1alpha1().EcnetConfigs().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("plugins"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Config().V1alpha1().Plugins().Informer()}, nil
 
 	}
 
@@ -22,3 +22,11 @@ type EcnetConfigListerExpansion interface{}
 // EcnetConfigNamespaceListerExpansion allows custom methods to be added to
 // EcnetConfigNamespaceLister.
 type EcnetConfigNamespaceListerExpansion interface{}
+
+// PluginListerExpansion allows custom methods to be added to
+// PluginLister.
+type PluginListerExpansion interface{}
+
+// PluginNamespaceListerExpansion allows custom methods to be added to
+// PluginNamespaceLister.
+type PluginNamespaceListerExpansion interface{}
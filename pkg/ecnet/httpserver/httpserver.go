@@ -24,23 +24,32 @@ type HTTPServer struct {
 	started      bool
 	server       *http.Server
 	httpServeMux *http.ServeMux // Used to restart the server once stopped
-	port         uint16         // Used to restart the server once stopped
+	addr         string         // Used to restart the server once stopped
 	stopSyncChan chan struct{}
 }
 
 // NewHTTPServer creates a new API server
 func NewHTTPServer(port uint16) *HTTPServer {
+	return newHTTPServer(fmt.Sprintf(":%d", port))
+}
+
+// NewLocalHTTPServer creates a new API server only reachable from the loopback interface
+func NewLocalHTTPServer(port uint16) *HTTPServer {
+	return newHTTPServer(fmt.Sprintf("127.0.0.1:%d", port))
+}
+
+func newHTTPServer(addr string) *HTTPServer {
 	serverMux := http.NewServeMux()
 
 	return &HTTPServer{
 		started: false,
 		server: &http.Server{
-			Addr:              fmt.Sprintf(":%d", port),
+			Addr:              addr,
 			Handler:           serverMux,
 			ReadHeaderTimeout: time.Second * 10,
 		},
 		httpServeMux: serverMux,
-		port:         port,
+		addr:         addr,
 		stopSyncChan: make(chan struct{}),
 	}
 }
@@ -98,7 +107,7 @@ func (s *HTTPServer) Stop() error {
 	// Free and reset the server, so it can be started again
 	s.started = false
 	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
+		Addr:    s.addr,
 		Handler: s.httpServeMux,
 		// Needs a default for gosec. This can probably be brought down to a lower value.
 		ReadHeaderTimeout: time.Second * 10,
@@ -33,16 +33,17 @@ func newClient(informerCollection *ecnetinformers.InformerCollection, msgBroker
 
 	// Initialize informers
 	informerInitHandlerMap := map[InformerKey]func(){
-		Namespaces:      c.initNamespaceMonitor,
-		Services:        c.initServicesMonitor,
-		ServiceAccounts: c.initServiceAccountsMonitor,
-		Pods:            c.initPodMonitor,
-		Endpoints:       c.initEndpointMonitor,
+		Namespaces:       c.initNamespaceMonitor,
+		Services:         c.initServicesMonitor,
+		ServiceAccounts:  c.initServiceAccountsMonitor,
+		Pods:             c.initPodMonitor,
+		Endpoints:        c.initEndpointMonitor,
+		CodebaseOverlays: c.initCodebaseOverlayMonitor,
 	}
 
 	// If specific informers are not selected to be initialized, initialize all informers
 	if len(selectInformers) == 0 {
-		selectInformers = []InformerKey{Namespaces, Services, ServiceAccounts, Pods, Endpoints}
+		selectInformers = []InformerKey{Namespaces, Services, ServiceAccounts, Pods, Endpoints, CodebaseOverlays}
 	}
 
 	for _, informer := range selectInformers {
@@ -110,6 +111,15 @@ func (c *client) initEndpointMonitor() {
 	c.informers.AddEventHandler(ecnetinformers.InformerKeyEndpoints, GetEventHandlerFuncs(c.shouldObserve, eptEventTypes, c.msgBroker))
 }
 
+func (c *client) initCodebaseOverlayMonitor() {
+	overlayEventTypes := EventTypes{
+		Add:    announcements.CodebaseOverlayAdded,
+		Update: announcements.CodebaseOverlayUpdated,
+		Delete: announcements.CodebaseOverlayDeleted,
+	}
+	c.informers.AddEventHandler(ecnetinformers.InformerKeyCodebaseOverlay, GetEventHandlerFuncs(nil, overlayEventTypes, c.msgBroker))
+}
+
 // IsMonitoredNamespace returns a boolean indicating if the namespace is among the list of monitored namespaces
 func (c client) IsMonitoredNamespace(namespace string) bool {
 	return c.informers.IsMonitoredNamespace(namespace)
@@ -210,6 +220,17 @@ func (c client) GetEndpoints(svc service.MeshService) (*corev1.Endpoints, error)
 	return nil, nil
 }
 
+// ListCodebaseOverlays returns the ConfigMaps holding user supplied overlays for the proxy codebase
+func (c client) ListCodebaseOverlays() []*corev1.ConfigMap {
+	var overlays []*corev1.ConfigMap
+
+	for _, cmInterface := range c.informers.List(ecnetinformers.InformerKeyCodebaseOverlay) {
+		cm := cmInterface.(*corev1.ConfigMap)
+		overlays = append(overlays, cm)
+	}
+	return overlays
+}
+
 // ListServiceIdentitiesForService lists ServiceAccounts associated with the given service
 func (c client) ListServiceIdentitiesForService(svc service.MeshService) ([]service.K8sServiceAccount, error) {
 	var svcAccounts []service.K8sServiceAccount
@@ -282,6 +303,11 @@ func ServiceToMeshServices(c Controller, svc corev1.Service) []service.MeshServi
 		// use port.appProtocol if specified, else use port protocol
 		meshSvc.Protocol = pointer.StringDeref(portSpec.AppProtocol, protocol)
 
+		// UDP ports can only be proxied as UDP, whatever the port name or appProtocol
+		if portSpec.Protocol == corev1.ProtocolUDP {
+			meshSvc.Protocol = constants.ProtocolUDP
+		}
+
 		// The endpoints for the kubernetes service carry information that allows
 		// us to retrieve the TargetPort for the MeshService.
 		endpoints, _ := c.GetEndpoints(meshSvc)
@@ -69,6 +69,17 @@ func WithKubeClient(kubeClient kubernetes.Interface) InformerCollectionOption {
 	}
 }
 
+// WithCodebaseOverlayClient sets the kubeClient used to watch the codebase overlay ConfigMaps for the InformerCollection
+func WithCodebaseOverlayClient(kubeClient kubernetes.Interface, ecnetNamespace string) InformerCollectionOption {
+	return func(ic *InformerCollection) {
+		option := informers.WithTweakListOptions(func(opt *metav1.ListOptions) {
+			opt.LabelSelector = fields.OneTermEqualSelector(constants.CodebaseOverlayLabel, "true").String()
+		})
+		informerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, DefaultKubeEventResyncInterval, informers.WithNamespace(ecnetNamespace), option)
+		ic.informers[InformerKeyCodebaseOverlay] = informerFactory.Core().V1().ConfigMaps().Informer()
+	}
+}
+
 // WithConfigClient sets the config client for the InformerCollection
 func WithConfigClient(configClient configClientset.Interface, ecnetConfigName, ecnetNamespace string) InformerCollectionOption {
 	return func(ic *InformerCollection) {
@@ -77,6 +88,9 @@ func WithConfigClient(configClient configClientset.Interface, ecnetConfigName, e
 		})
 		ecnetConfiginformerFactory := configInformers.NewSharedInformerFactoryWithOptions(configClient, DefaultKubeEventResyncInterval, configInformers.WithNamespace(ecnetNamespace), listOption)
 		ic.informers[InformerKeyEcnetConfig] = ecnetConfiginformerFactory.Config().V1alpha1().EcnetConfigs().Informer()
+
+		pluginInformerFactory := configInformers.NewSharedInformerFactoryWithOptions(configClient, DefaultKubeEventResyncInterval, configInformers.WithNamespace(ecnetNamespace))
+		ic.informers[InformerKeyPlugin] = pluginInformerFactory.Config().V1alpha1().Plugins().Informer()
 	}
 }
 
@@ -21,9 +21,13 @@ const (
 	InformerKeyEndpoints InformerKey = "Endpoints"
 	// InformerKeyServiceAccount is the InformerKey for a ServiceAccount informer
 	InformerKeyServiceAccount InformerKey = "ServiceAccount"
+	// InformerKeyCodebaseOverlay is the InformerKey for a codebase overlay ConfigMap informer
+	InformerKeyCodebaseOverlay InformerKey = "CodebaseOverlay"
 
 	// InformerKeyEcnetConfig is the InformerKey for a EcnetConfig informer
 	InformerKeyEcnetConfig InformerKey = "EcnetConfig"
+	// InformerKeyPlugin is the InformerKey for a Plugin informer
+	InformerKeyPlugin InformerKey = "Plugin"
 	// InformerKeyServiceImport is the InformerKey for a ServiceImport informer
 	InformerKeyServiceImport InformerKey = "ServiceImport"
 	// InformerKeyGlobalTrafficPolicy is the InformerKey for a GlobalTrafficPolicy informer
@@ -80,6 +80,18 @@ func (pf *PortForwarder) Stop() {
 	}
 }
 
+// LocalPort returns the local port forwarded to the pod, once the port forwarding is ready
+func (pf *PortForwarder) LocalPort() (uint16, error) {
+	ports, err := pf.forwarder.GetPorts()
+	if err != nil {
+		return 0, err
+	}
+	if len(ports) == 0 {
+		return 0, fmt.Errorf("No port forwarded")
+	}
+	return ports[0].Local, nil
+}
+
 // Done returns a channel that is closed after Stop has been called.
 func (pf *PortForwarder) Done() <-chan struct{} {
 	return pf.done
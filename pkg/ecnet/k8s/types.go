@@ -46,6 +46,8 @@ const (
 	Endpoints InformerKey = "Endpoints"
 	// ServiceAccounts lookup identifier
 	ServiceAccounts InformerKey = "ServiceAccounts"
+	// CodebaseOverlays lookup identifier
+	CodebaseOverlays InformerKey = "CodebaseOverlays"
 )
 
 // client is the type used to represent the k8s client for the native k8s resources
@@ -86,4 +88,7 @@ type Controller interface {
 	GetEndpoints(service.MeshService) (*corev1.Endpoints, error)
 
 	GetTargetPortForServicePort(types.NamespacedName, uint16) (uint16, error)
+
+	// ListCodebaseOverlays returns the ConfigMaps holding user supplied overlays for the proxy codebase
+	ListCodebaseOverlays() []*corev1.ConfigMap
 }
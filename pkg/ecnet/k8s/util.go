@@ -12,15 +12,14 @@ import (
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
 )
 
-var (
-	trustDomain = `cluster.local`
-)
-
-// SetTrustDomain sets the trust domain
+// SetTrustDomain sets the trust domain, which is also the DNS domain of the local cluster
 func SetTrustDomain(domain string) {
-	if len(domain) > 0 {
-		trustDomain = domain
-	}
+	service.SetClusterDomain(domain)
+}
+
+// GetTrustDomain returns the trust domain, ie: cluster.local
+func GetTrustDomain() string {
+	return service.GetClusterDomain()
 }
 
 // GetHostnamesForService returns the hostnames over which the service is accessible
@@ -45,7 +44,7 @@ func GetHostnamesForService(svc service.MeshService, localNamespace bool) []stri
 		//fmt.Sprintf("%s.%s.svc.cluster.local:%d", svc.Name, svc.Namespace, svc.Port), // service.namespace.svc.cluster.local:port
 	}...)
 
-	segs := strings.Split(trustDomain, ".")
+	segs := strings.Split(service.GetClusterDomain(), ".")
 	if len(segs) > 0 {
 		hostname := fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace)
 		for _, seg := range segs {
@@ -58,14 +57,42 @@ func GetHostnamesForService(svc service.MeshService, localNamespace bool) []stri
 		}
 	}
 
+	// Services imported from the cluster set are also reachable over the clusterset.local domain
+	if svc.IsMultiClusterService() {
+		hostnames = append(hostnames, []string{
+			svc.ClusterSetFQDN(), // service.namespace.svc.clusterset.local
+			fmt.Sprintf("%s:%d", svc.ClusterSetFQDN(), svc.Port), // service.namespace.svc.clusterset.local:port
+		}...)
+	}
+
 	return hostnames
 }
 
+// GetServerNamesForService returns the server names a TLS client may send as SNI to reach the given service
+func GetServerNamesForService(svc service.MeshService) []string {
+	var serverNames []string
+	for _, hostname := range GetHostnamesForService(svc, false) {
+		// SNI never carries the port
+		if !strings.Contains(hostname, ":") {
+			serverNames = append(serverNames, hostname)
+		}
+	}
+	return serverNames
+}
+
 // splitHostName takes a k8s FQDN (i.e. host) and retrieves the service name
 // as well as the subdomain (may be empty)
 func splitHostName(c Controller, host string) (svc string, subdomain string) {
 	host = strings.Split(host, ":")[0] // chop port off the end
 
+	// chop the cluster or cluster set domain off the end, leaving [subdomain.]service.namespace.svc
+	for _, domain := range []string{service.GetClusterDomain(), service.ClusterSetDomain} {
+		if trimmed := strings.TrimSuffix(host, ".svc."+domain); trimmed != host {
+			host = trimmed + ".svc"
+			break
+		}
+	}
+
 	serviceComponents := strings.Split(host, ".")
 
 	// The service name is usually the first string in the host name for a service.
@@ -150,14 +177,12 @@ func splitHostName(c Controller, host string) (svc string, subdomain string) {
 }
 
 // GetServiceFromHostname returns the service name from its hostname
-// This assumes the default k8s trustDomain: cluster.local
 func GetServiceFromHostname(c Controller, host string) string {
 	svc, _ := splitHostName(c, host)
 	return svc
 }
 
 // GetSubdomainFromHostname returns the service subdomain from its hostname
-// This assumes the default k8s trustDomain: cluster.local
 func GetSubdomainFromHostname(c Controller, host string) string {
 	_, subdomain := splitHostName(c, host)
 	return subdomain
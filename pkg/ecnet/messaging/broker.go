@@ -16,6 +16,7 @@ import (
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/announcements"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s/events"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/metricsstore"
 )
 
 const (
@@ -160,6 +161,8 @@ func (b *Broker) runProxyUpdateDispatcher(stopCh <-chan struct{}) {
 			maxTimer.Reset(noTimeout)
 			b.proxyUpdatePubSub.Pub(event.msg, event.topic)
 			atomic.AddUint64(&b.totalDispatchedProxyEventCount, 1)
+			metricsstore.DefaultMetricsStore.ProxyUpdateDispatchCounter.Inc()
+			metricsstore.DefaultMetricsStore.ProxyUpdateBatchSize.Observe(float64(batchCount))
 			log.Trace().Msgf("Sliding window expired, msg kind %s, batch size %d", event.msg.Kind, batchCount)
 			dispatchPending = false
 			batchCount = 0
@@ -174,6 +177,8 @@ func (b *Broker) runProxyUpdateDispatcher(stopCh <-chan struct{}) {
 			slidingTimer.Reset(noTimeout)
 			b.proxyUpdatePubSub.Pub(event.msg, event.topic)
 			atomic.AddUint64(&b.totalDispatchedProxyEventCount, 1)
+			metricsstore.DefaultMetricsStore.ProxyUpdateDispatchCounter.Inc()
+			metricsstore.DefaultMetricsStore.ProxyUpdateBatchSize.Observe(float64(batchCount))
 			log.Trace().Msgf("Max window expired, msg kind %s, batch size %d", event.msg.Kind, batchCount)
 			dispatchPending = false
 			batchCount = 0
@@ -192,15 +197,18 @@ func (b *Broker) runProxyUpdateDispatcher(stopCh <-chan struct{}) {
 // 3. Updates metrics associated with the event
 func (b *Broker) processEvent(msg events.PubSubMessage) {
 	log.Trace().Msgf("Processing msg kind: %s", msg.Kind)
+	metricsstore.DefaultMetricsStore.EventCounter.WithLabelValues(msg.Kind.String()).Inc()
 	// Update proxies if applicable
 	if event := getProxyUpdateEvent(msg); event != nil {
 		log.Trace().Msgf("Msg kind %s will update proxies", msg.Kind)
 		atomic.AddUint64(&b.totalQProxyEventCount, 1)
+		metricsstore.DefaultMetricsStore.ProxyUpdateEventCounter.Inc()
 		if event.topic != announcements.ProxyUpdate.String() {
 			// This is not a broadcast event, so it cannot be coalesced with
 			// other events as the event is specific to one or more proxies.
 			b.proxyUpdatePubSub.Pub(event.msg, event.topic)
 			atomic.AddUint64(&b.totalDispatchedProxyEventCount, 1)
+			metricsstore.DefaultMetricsStore.ProxyUpdateDispatchCounter.Inc()
 		} else {
 			// Pass the broadcast event to the dispatcher routine, that coalesces
 			// multiple broadcasts received in close proximity.
@@ -242,6 +250,15 @@ func getProxyUpdateEvent(msg events.PubSubMessage) *proxyUpdateEvent {
 		// GlobalTrafficPolicy event
 		announcements.GlobalTrafficPolicyAdded, announcements.GlobalTrafficPolicyDeleted, announcements.GlobalTrafficPolicyUpdated,
 		//
+		// Codebase overlay events
+		//
+		announcements.CodebaseOverlayAdded, announcements.CodebaseOverlayDeleted, announcements.CodebaseOverlayUpdated,
+		//
+		// config.flomesh.io events
+		//
+		// Plugin event
+		announcements.PluginAdded, announcements.PluginDeleted, announcements.PluginUpdated,
+		//
 		// Proxy events
 		//
 		announcements.ProxyUpdate:
@@ -263,6 +280,7 @@ func getProxyUpdateEvent(msg events.PubSubMessage) *proxyUpdateEvent {
 		// A proxy config update must only be triggered when a EcnetConfig field that maps to a proxy config
 		// changes.
 		if prevSpec.Sidecar.LogLevel != newSpec.Sidecar.LogLevel ||
+			!reflect.DeepEqual(prevSpec.Observability, newSpec.Observability) ||
 			// Only trigger an update on InboundExternalAuthorization field changes if the new spec has the 'Enable' flag set to true.
 			!reflect.DeepEqual(prevSpec.PluginChains, newSpec.PluginChains) {
 			return &proxyUpdateEvent{
@@ -0,0 +1,220 @@
+// Package metricsstore implements a centralized store for the metrics of ECNET's control plane internals.
+package metricsstore
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// metricsRootNamespace is the root namespace of all ECNET metrics
+	metricsRootNamespace = "ecnet"
+)
+
+// MetricsStore is a type that provides functionality related to metrics
+type MetricsStore struct {
+	// Any metrics that are added to the MetricsStore must be registered in Start()
+
+	/*
+	 * Event metrics
+	 */
+
+	// EventCounter is the metric counter for the number of events observed by the message broker, by kind
+	EventCounter *prometheus.CounterVec
+
+	// ProxyUpdateEventCounter is the metric counter for the number of events resulting in proxy updates
+	ProxyUpdateEventCounter prometheus.Counter
+
+	// ProxyUpdateDispatchCounter is the metric counter for the number of proxy update events dispatched to proxies
+	ProxyUpdateDispatchCounter prometheus.Counter
+
+	// ProxyUpdateBatchSize is the histogram for the number of proxy update events coalesced per dispatch
+	ProxyUpdateBatchSize prometheus.Histogram
+
+	/*
+	 * Proxy config metrics
+	 */
+
+	// ProxyConfigUpdateTime is the histogram to track the time spent generating and publishing a proxy config
+	ProxyConfigUpdateTime prometheus.Histogram
+
+	// ProxyConfigSize is the histogram for the size in bytes of the proxy configs published
+	ProxyConfigSize prometheus.Histogram
+
+	/*
+	 * Pipy repo metrics
+	 */
+
+	// RepoAPIRequestTime is the histogram to track the latency of the requests sent to the pipy repo, by method and status code
+	RepoAPIRequestTime *prometheus.HistogramVec
+
+	// RepoAPIErrorCounter is the metric counter for the number of failed requests sent to the pipy repo, by method
+	RepoAPIErrorCounter *prometheus.CounterVec
+
+	/*
+	 * Bridge metrics
+	 */
+
+	// BridgeNatEvictionCounter is the metric counter for the number of nat entries evicted by ecnet-bridge, by map and reason
+	BridgeNatEvictionCounter *prometheus.CounterVec
+
+	/*
+	 * Error code metrics
+	 */
+
+	// ErrCodeCounter is the metric counter for the number of errors logged, by error code
+	ErrCodeCounter *prometheus.CounterVec
+
+	/*
+	 * MetricsStore internals should be defined below --------------
+	 */
+	registry *prometheus.Registry
+	mutex    sync.Mutex
+}
+
+// DefaultMetricsStore is the default metrics store
+var DefaultMetricsStore = &MetricsStore{}
+
+func init() {
+	/*
+	 * Event metrics
+	 */
+	DefaultMetricsStore.EventCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "broker",
+			Name:      "event_total",
+			Help:      "Represents the number of events observed by the message broker",
+		},
+		[]string{"kind"},
+	)
+
+	DefaultMetricsStore.ProxyUpdateEventCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "broker",
+			Name:      "proxy_update_event_total",
+			Help:      "Represents the number of events resulting in proxy updates",
+		},
+	)
+
+	DefaultMetricsStore.ProxyUpdateDispatchCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "broker",
+			Name:      "proxy_update_dispatch_total",
+			Help:      "Represents the number of proxy update events dispatched to proxies",
+		},
+	)
+
+	DefaultMetricsStore.ProxyUpdateBatchSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "broker",
+			Name:      "proxy_update_batch_size",
+			Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500},
+			Help:      "Histogram for the number of proxy update events coalesced per dispatch",
+		},
+	)
+
+	/*
+	 * Proxy config metrics
+	 */
+	DefaultMetricsStore.ProxyConfigUpdateTime = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "proxy",
+			Name:      "config_update_time_seconds",
+			Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 20},
+			Help:      "Histogram to track time spent generating and publishing a proxy config",
+		},
+	)
+
+	DefaultMetricsStore.ProxyConfigSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "proxy",
+			Name:      "config_size_bytes",
+			Buckets:   prometheus.ExponentialBuckets(1024, 2, 12),
+			Help:      "Histogram for the size in bytes of the proxy configs published",
+		},
+	)
+
+	/*
+	 * Pipy repo metrics
+	 */
+	DefaultMetricsStore.RepoAPIRequestTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "repo",
+			Name:      "api_request_time_seconds",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+			Help:      "Histogram to track the latency of the requests sent to the pipy repo",
+		},
+		[]string{"method", "code"},
+	)
+
+	DefaultMetricsStore.RepoAPIErrorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "repo",
+			Name:      "api_error_total",
+			Help:      "Represents the number of failed requests sent to the pipy repo",
+		},
+		[]string{"method"},
+	)
+
+	/*
+	 * Bridge metrics
+	 */
+	DefaultMetricsStore.BridgeNatEvictionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsRootNamespace,
+			Subsystem: "bridge",
+			Name:      "nat_eviction_total",
+			Help:      "Represents the number of nat entries evicted by ecnet-bridge, or by the LRU of the nat maps",
+		},
+		[]string{"map", "reason"},
+	)
+
+	/*
+	 * Error code metrics
+	 */
+	DefaultMetricsStore.ErrCodeCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsRootNamespace,
+			Name:      "error_err_code_total",
+			Help:      "Number of errors encountered, by error code",
+		},
+		[]string{"err_code"},
+	)
+
+	DefaultMetricsStore.registry = prometheus.NewRegistry()
+}
+
+// Start store
+func (ms *MetricsStore) Start(cs ...prometheus.Collector) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.registry.MustRegister(cs...)
+}
+
+// Stop store
+func (ms *MetricsStore) Stop(cs ...prometheus.Collector) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	for _, c := range cs {
+		ms.registry.Unregister(c)
+	}
+}
+
+// Handler return the registry
+func (ms *MetricsStore) Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		ms.registry,
+		promhttp.HandlerFor(ms.registry, promhttp.HandlerOpts{}),
+	)
+}
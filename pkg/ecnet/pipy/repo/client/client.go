@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/go-resty/resty/v2"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/metricsstore"
 )
 
 const (
@@ -95,7 +97,18 @@ func NewRepoClientWithAPIBaseURLAndTransport(serverAddr string, serverPort uint1
 		SetBaseURL(repo.apiURI.baseURI).
 		SetTimeout(90 * time.Second).
 		SetDebug(false).
-		EnableTrace()
+		EnableTrace().
+		OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+			metricsstore.DefaultMetricsStore.RepoAPIRequestTime.
+				WithLabelValues(resp.Request.Method, strconv.Itoa(resp.StatusCode())).Observe(resp.Time().Seconds())
+			if resp.StatusCode() >= http.StatusInternalServerError {
+				metricsstore.DefaultMetricsStore.RepoAPIErrorCounter.WithLabelValues(resp.Request.Method).Inc()
+			}
+			return nil
+		}).
+		OnError(func(req *resty.Request, _ error) {
+			metricsstore.DefaultMetricsStore.RepoAPIErrorCounter.WithLabelValues(req.Method).Inc()
+		})
 
 	return repo
 }
@@ -234,6 +247,28 @@ func (p *PipyRepoClient) upsertFile(path string, content interface{}) (success b
 	return
 }
 
+// GetFile retrieves the content of codebase file
+func (p *PipyRepoClient) GetFile(path string) (success bool, content []byte, err error) {
+	var resp *resty.Response
+
+	resp, err = p.httpClient.R().
+		Get(fmt.Sprintf("%s/%s", p.apiURI.repoFilesURI, path))
+
+	if err == nil {
+		success = true
+		switch resp.StatusCode() {
+		case http.StatusOK:
+			content = resp.Body()
+			return
+		default:
+			return
+		}
+	}
+
+	log.Err(err).Msgf("error happened while getting file[%s]", path)
+	return
+}
+
 // Delete codebase
 func (p *PipyRepoClient) Delete(codebaseName string) (success bool, err error) {
 	var resp *resty.Response
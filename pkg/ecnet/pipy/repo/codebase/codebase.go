@@ -100,15 +100,27 @@ var codebaseModulesOutboundTCPLoadBalancingJs []byte
 //go:embed proxy/modules/outbound-tcp-routing.js
 var codebaseModulesOutboundTCPRoutingJs []byte
 
+//go:embed proxy/modules/outbound-throttle-route.js
+var codebaseModulesOutboundThrottleRouteJs []byte
+
+//go:embed proxy/modules/outbound-throttle-service.js
+var codebaseModulesOutboundThrottleServiceJs []byte
+
 //go:embed proxy/modules/outbound-tracing-http.js
 var codebaseModulesOutboundTracingHTTPJs []byte
 
+//go:embed proxy/modules/outbound-udp-main.js
+var codebaseModulesOutboundUDPMainJs []byte
+
 //go:embed proxy/probes.js
 var codebaseProbesJs []byte
 
 //go:embed proxy/stats.js
 var codebaseStatsJs []byte
 
+//go:embed proxy/throttle.js
+var codebaseThrottleJs []byte
+
 //go:embed proxy/tracing.js
 var codebaseTracingJs []byte
 
@@ -156,9 +168,13 @@ var EcnetCodebaseItems = []client.BatchItem{
 	{Filename: "modules/outbound-tcp-default.js", Content: codebaseModulesOutboundTCPDefaultJs},
 	{Filename: "modules/outbound-tcp-load-balancing.js", Content: codebaseModulesOutboundTCPLoadBalancingJs},
 	{Filename: "modules/outbound-tcp-routing.js", Content: codebaseModulesOutboundTCPRoutingJs},
+	{Filename: "modules/outbound-throttle-route.js", Content: codebaseModulesOutboundThrottleRouteJs},
+	{Filename: "modules/outbound-throttle-service.js", Content: codebaseModulesOutboundThrottleServiceJs},
 	{Filename: "modules/outbound-tracing-http.js", Content: codebaseModulesOutboundTracingHTTPJs},
+	{Filename: "modules/outbound-udp-main.js", Content: codebaseModulesOutboundUDPMainJs},
 	{Filename: "probes.js", Content: codebaseProbesJs},
 	{Filename: "stats.js", Content: codebaseStatsJs},
+	{Filename: "throttle.js", Content: codebaseThrottleJs},
 	{Filename: "tracing.js", Content: codebaseTracingJs},
 	{Filename: "utils.js", Content: codebaseUtilsJs},
 
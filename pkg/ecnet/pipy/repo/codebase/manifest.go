@@ -0,0 +1,38 @@
+package codebase
+
+import (
+	"fmt"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/util"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/version"
+)
+
+const (
+	// EcnetCodebaseManifest defines manifest file name.
+	EcnetCodebaseManifest = "manifest.json"
+
+	// ConfigSchemaVersion is the version of config.json schema understood by the proxy codebase,
+	// it must be increased on every incompatible change of config.json.
+	ConfigSchemaVersion = 1
+)
+
+// Manifest describes the version of proxy codebase.
+type Manifest struct {
+	// Version is the controller version plus the content hash of the static resources
+	Version string `json:"Version"`
+	// ConfigSchema is the version of config.json schema understood by the codebase
+	ConfigSchema int `json:"ConfigSchema"`
+}
+
+// GetManifest returns the manifest of the static resources of proxy codebase.
+func GetManifest() Manifest {
+	var bytes []byte
+	for _, item := range EcnetCodebaseItems {
+		bytes = append(bytes, []byte(item.Filename)...)
+		bytes = append(bytes, item.Content.([]byte)...)
+	}
+	return Manifest{
+		Version:      fmt.Sprintf("%s-%d", version.Version, util.Hash(bytes)),
+		ConfigSchema: ConfigSchemaVersion,
+	}
+}
@@ -0,0 +1,257 @@
+package codebase
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/client"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/util"
+)
+
+const (
+	// MaxOverlayFileSize defines the max size of a single overlay file.
+	MaxOverlayFileSize = 256 * 1024
+
+	// MaxOverlayTotalSize defines the max size of all overlay files.
+	MaxOverlayTotalSize = 1024 * 1024
+)
+
+// Overlay is a user supplied file which replaces or adds a file of the base codebase.
+type Overlay struct {
+	// Source identifies where the overlay comes from, ex. namespace/configmap
+	Source string
+	// Filename is the path of the file relative to the base codebase
+	Filename string
+	// Content is the content of the file
+	Content []byte
+}
+
+// ValidateOverlay checks the name, the size and the syntax of an overlay file.
+func ValidateOverlay(overlay Overlay) error {
+	filename := overlay.Filename
+	if len(filename) == 0 || path.IsAbs(filename) || path.Clean(filename) != filename || strings.HasPrefix(filename, "..") {
+		return fmt.Errorf("invalid overlay file name %q", filename)
+	}
+	if filename == EcnetCodebaseConfig || filename == EcnetCodebaseManifest {
+		return fmt.Errorf("overlay file %q is generated by the controller and can't be overridden", filename)
+	}
+	if len(overlay.Content) > MaxOverlayFileSize {
+		return fmt.Errorf("overlay file %q exceeds the size limit, %d > %d", filename, len(overlay.Content), MaxOverlayFileSize)
+	}
+
+	switch path.Ext(filename) {
+	case ".js":
+		if err := checkScriptSyntax(overlay.Content); err != nil {
+			return fmt.Errorf("overlay file %q is not a valid script: %v", filename, err)
+		}
+		return nil
+	case ".json":
+		if !json.Valid(overlay.Content) {
+			return fmt.Errorf("overlay file %q is not a valid json", filename)
+		}
+		return nil
+	default:
+		return fmt.Errorf("overlay file %q must be a .js or .json file", filename)
+	}
+}
+
+// MergeOverlays merges overlays into the static resources of proxy codebase,
+// returns the merged items and the version of overlays.
+func MergeOverlays(overlays []Overlay) ([]client.BatchItem, uint64, error) {
+	sorted := make([]Overlay, len(overlays))
+	copy(sorted, overlays)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Filename < sorted[j].Filename
+	})
+
+	totalSize := 0
+	overlayItems := make(map[string]Overlay)
+	versionItems := make([]client.BatchItem, 0, len(sorted))
+	for _, overlay := range sorted {
+		if existing, exists := overlayItems[overlay.Filename]; exists {
+			return nil, 0, fmt.Errorf("overlay file %q is supplied by both %s and %s", overlay.Filename, existing.Source, overlay.Source)
+		}
+		totalSize += len(overlay.Content)
+		overlayItems[overlay.Filename] = overlay
+		versionItems = append(versionItems, client.BatchItem{Filename: overlay.Filename, Content: overlay.Content})
+	}
+	if totalSize > MaxOverlayTotalSize {
+		return nil, 0, fmt.Errorf("overlay files exceed the size limit, %d > %d", totalSize, MaxOverlayTotalSize)
+	}
+
+	items := make([]client.BatchItem, 0, len(EcnetCodebaseItems)+len(overlayItems))
+	for _, item := range EcnetCodebaseItems {
+		if overlay, exists := overlayItems[item.Filename]; exists {
+			item.Content = overlay.Content
+			delete(overlayItems, item.Filename)
+		}
+		items = append(items, item)
+	}
+	for _, overlay := range sorted {
+		if _, exists := overlayItems[overlay.Filename]; exists {
+			items = append(items, client.BatchItem{Filename: overlay.Filename, Content: overlay.Content})
+		}
+	}
+
+	var version uint64
+	if len(versionItems) > 0 {
+		version = HashItems(versionItems)
+	}
+	return items, version, nil
+}
+
+// IsEmbedded returns whether the file is one of the static resources of proxy codebase.
+func IsEmbedded(filename string) bool {
+	for _, item := range EcnetCodebaseItems {
+		if item.Filename == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// HashItems returns the hash of the names and the contents of batch items regardless of their order,
+// every field is prefixed with its length so that the fields can't run into each other.
+func HashItems(items []client.BatchItem) uint64 {
+	sorted := make([]client.BatchItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Path+"/"+sorted[i].Filename < sorted[j].Path+"/"+sorted[j].Filename
+	})
+
+	var bytes []byte
+	appendField := func(field []byte) {
+		bytes = binary.AppendUvarint(bytes, uint64(len(field)))
+		bytes = append(bytes, field...)
+	}
+	for _, item := range sorted {
+		appendField([]byte(item.Path))
+		appendField([]byte(item.Filename))
+		if item.Obsolete {
+			bytes = append(bytes, 1)
+			continue
+		}
+		bytes = append(bytes, 0)
+		switch content := item.Content.(type) {
+		case []byte:
+			appendField(content)
+		case string:
+			appendField([]byte(content))
+		default:
+			appendField([]byte(fmt.Sprintf("%v", content)))
+		}
+	}
+	return util.Hash(bytes)
+}
+
+// checkScriptSyntax does a lexical check of a pjs script: comments, string, template
+// and regular expression literals must be terminated and brackets must be balanced.
+func checkScriptSyntax(script []byte) error {
+	var brackets []byte
+	var templates []int
+	var prev byte
+	line := 1
+
+	unterminated := func(what string, at int) error {
+		return fmt.Errorf("unterminated %s at line %d", what, at)
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			continue
+		case c == '/' && i+1 < len(script) && script[i+1] == '/':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			i--
+			continue
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			start := line
+			end := strings.Index(string(script[i+2:]), "*/")
+			if end < 0 {
+				return unterminated("comment", start)
+			}
+			line += strings.Count(string(script[i:i+2+end]), "\n")
+			i += end + 3
+			continue
+		case c == '/' && (prev == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^", prev) >= 0):
+			start := line
+			inClass := false
+			for i++; ; i++ {
+				if i >= len(script) || script[i] == '\n' {
+					return unterminated("regular expression", start)
+				}
+				if script[i] == '\\' {
+					i++
+				} else if script[i] == '[' {
+					inClass = true
+				} else if script[i] == ']' {
+					inClass = false
+				} else if script[i] == '/' && !inClass {
+					break
+				}
+			}
+			c = 'a'
+		case c == '\'' || c == '"':
+			start := line
+			for i++; ; i++ {
+				if i >= len(script) || script[i] == '\n' {
+					return unterminated("string", start)
+				}
+				if script[i] == '\\' {
+					i++
+				} else if script[i] == c {
+					break
+				}
+			}
+			c = 'a'
+		case c == '`' || (c == '}' && len(templates) > 0 && templates[len(templates)-1] == len(brackets)):
+			if c == '}' {
+				templates = templates[:len(templates)-1]
+			}
+			start := line
+			for i++; ; i++ {
+				if i >= len(script) {
+					return unterminated("template", start)
+				}
+				if script[i] == '\n' {
+					line++
+				} else if script[i] == '\\' {
+					i++
+				} else if script[i] == '`' {
+					break
+				} else if script[i] == '$' && i+1 < len(script) && script[i+1] == '{' {
+					i++
+					templates = append(templates, len(brackets))
+					break
+				}
+			}
+			c = 'a'
+		case c == '(' || c == '[' || c == '{':
+			brackets = append(brackets, c)
+		case c == ')' || c == ']' || c == '}':
+			if len(brackets) == 0 || brackets[len(brackets)-1] != map[byte]byte{')': '(', ']': '[', '}': '{'}[c] {
+				return fmt.Errorf("unexpected %q at line %d", c, line)
+			}
+			brackets = brackets[:len(brackets)-1]
+		}
+		prev = c
+	}
+
+	if len(templates) > 0 {
+		return unterminated("template", line)
+	}
+	if len(brackets) > 0 {
+		return fmt.Errorf("unclosed %q at end of script", brackets[len(brackets)-1])
+	}
+	return nil
+}
@@ -2,26 +2,79 @@
   config = pipy.solve('config.js'),
   dnsServers = { primary: config?.Spec?.LocalDNSProxy?.UpstreamDNSServers?.Primary, secondary: config?.Spec?.LocalDNSProxy?.UpstreamDNSServers?.Secondary },
   dnsSvcAddress = (dnsServers?.primary || dnsServers?.secondary || os.env.LOCAL_DNS_PROXY_PRIMARY_UPSTREAM || '10.96.0.10') + ":53",
+  ttl = config?.Spec?.LocalDNSProxy?.TTL || 600,
+  negativeTTL = config?.Spec?.LocalDNSProxy?.NegativeTTL || 30,
+  searchDomains = config?.Spec?.LocalDNSProxy?.SearchDomains || [],
   dnsRecordSets = {},
+  negativeCache = {},
+  negativeCacheSize = 0,
   bridgeIP = pipy.exec('ip -4 addr show dev ' + (os.env.CNI_BRIDGE_ETH || 'cni0')).toString().split('\n').find(s => s.trim().startsWith('inet'))?.trim?.()?.split?.(' ')?.[1]?.split?.('/')?.[0],
+  // The virtual IPs of the ClusterSetIP imports are routed to the bridge by the tc programs
+  clusterSetVIPs = config?.Spec?.ClusterSet?.VIPCIDR && new Netmask(config.Spec.ClusterSet.VIPCIDR),
+  clusterDomain = '.' + (config?.Spec?.ClusterDomain || 'cluster.local').toLowerCase(),
+
+  // The names are case-insensitive, the records are keyed on the lower case ones
+  lookup = name => (
+    dnsRecordSets[name] || searchDomains.map(domain => dnsRecordSets[name + '.' + domain.toLowerCase()]).find(rr => rr)
+  ),
+
+  // The cluster set domain is only served here, a name of it missing from the records does not exist,
+  // neither does any name of it expanded with the search domains of the pod
+  isClusterSetName = name => (
+    name.endsWith('.clusterset.local') || name.includes('.clusterset.local.')
+  ),
+
+  soa = name => ({
+    'name': name,
+    'type': 'SOA',
+    'ttl': negativeTTL,
+    'rdata': {
+      'mname': 'localhost',
+      'rname': 'admin.localhost',
+      'serial': 1663232447,
+      'refresh': 1800,
+      'retry': 900,
+      'expire': 604800,
+      'minimum': negativeTTL
+    }
+  }),
+
+  respond = (dns, rcode, answer, authority) => (
+    dns.qr = 1,
+    dns.rd = 1,
+    dns.ra = 1,
+    dns.aa = 1,
+    dns.rcode = rcode,
+    dns.question = [{
+      'name': dns.question[0].name,
+      'type': dns.question[0].type
+    }],
+    dns.answer = answer,
+    dns.authority = authority,
+    dns.additional = [],
+    new Message(DNS.encode(dns))
+  ),
 ) => (
   config?.DNSResolveDB && (
     Object.entries(config.DNSResolveDB).map(
       ([k, v]) => (
-        ((rr) => (
-          rr = [],
-          v.map(
-            ip => (
-              rr.push({
-                'name': k,
-                'type': 'A',
-                'ttl': 600, // TTL : 10 minutes
-                'rdata': bridgeIP || ip
-              })
-            )
-          ),
-          dnsRecordSets[k] = rr
-        ))()
+        dnsRecordSets[k] = v.map(
+          r => (r.Type === 'SRV') ? ({
+            'type': 'SRV',
+            'ttl': ttl,
+            'rdata': {
+              'priority': 0,
+              'weight': 1,
+              'port': r.Port,
+              'target': r.Target
+            }
+          }) : ({
+            'type': r.Type,
+            'ttl': ttl,
+            // The imported services are reached through the bridge unless they have a virtual IP
+            'rdata': clusterSetVIPs?.contains?.(r.Address) ? r.Address : (bridgeIP || r.Address)
+          })
+        )
       )
     )
   ),
@@ -34,23 +87,20 @@
   .replaceMessage(
     msg => (
       _response = null,
-      ((dns, answer) => (
+      ((dns, name, key, type, rr, answer) => (
         dns = DNS.decode(msg.body),
-        (dns?.question?.[0]?.type === 'A') && (
-          (answer = dnsRecordSets[dns?.question?.[0]?.name]) && (
-            dns.qr = 1,
-            dns.rd = 1,
-            dns.ra = 1,
-            dns.aa = 1,
-            dns.rcode = 0,
-            dns.question = [{
-              'name': dns.question[0].name,
-              'type': dns.question[0].type
-            }],
-            dns.answer = answer,
-            dns.authority = [],
-            dns.additional = [],
-            _response = new Message(DNS.encode(dns))
+        name = dns?.question?.[0]?.name,
+        key = name?.toLowerCase?.(),
+        type = dns?.question?.[0]?.type,
+        name && (
+          (rr = lookup(key)) ? (
+            answer = rr.filter(r => r.type === type).map(r => ({ 'name': name, 'type': r.type, 'ttl': r.ttl, 'rdata': r.rdata })),
+            // NODATA when the name only has records of other types, AAAA included as the bridge intercepts IPv4 alone
+            _response = respond(dns, 0, answer, answer.length > 0 ? [] : [soa(name)])
+          ) : (
+            (isClusterSetName(key) || negativeCache[key] > Date.now()) && (
+              _response = respond(dns, 3, [], [soa(name)])
+            )
           )
         )
       ))(),
@@ -72,10 +122,16 @@
             ),
             (dns?.authority?.length > 0 && (nsname = dns?.authority?.[0]?.name)) && (
               // exclude domain suffix : search svc.cluster.local cluster.local
-              name.endsWith('.cluster.local') && nsname && (fake = false)
+              name.toLowerCase().endsWith(clusterDomain) && nsname && (fake = false)
             )
           ),
 
+          // cache the names that do not exist rather than forwarding them upstream again
+          (dns?.rcode === 3 && !fake && name) && (
+            (negativeCacheSize++ > 10000) && (negativeCache = {}, negativeCacheSize = 0),
+            negativeCache[name.toLowerCase()] = Date.now() + negativeTTL * 1000
+          ),
+
           fake && (
             dns.qr = 1,
             dns.rd = 1,
@@ -5,11 +5,16 @@
       kind,
       name,
       pod,
+      toSampledFraction,
+      isTraceSampled,
     } = pipy.solve('utils.js'),
-    address = os.env.REMOTE_LOGGING_ADDRESS,
-    tracingLimitedID = os.env.REMOTE_LOGGING_SAMPLED_FRACTION && (os.env.REMOTE_LOGGING_SAMPLED_FRACTION * Math.pow(2, 63)),
+    config = pipy.solve('config.js'),
+    remoteLogging = config?.Spec?.RemoteLogging,
+    address = remoteLogging?.Address || os.env.REMOTE_LOGGING_ADDRESS,
+    sampledFraction = toSampledFraction(remoteLogging ? remoteLogging.SampledFraction : os.env.REMOTE_LOGGING_SAMPLED_FRACTION),
+    fields = remoteLogging?.Fields?.length > 0 ? remoteLogging.Fields : null,
     logLogging = address && new logging.JSONLogger('access-logger').toHTTP('http://' + address +
-      (os.env.REMOTE_LOGGING_ENDPOINT || '/?query=insert%20into%20log(message)%20format%20JSONAsString'), {
+      (remoteLogging?.Endpoint || os.env.REMOTE_LOGGING_ENDPOINT || '/?query=insert%20into%20log(message)%20format%20JSONAsString'), {
       batch: {
         timeout: 1,
         interval: 1,
@@ -19,12 +24,11 @@
       },
       headers: {
         'Content-Type': 'application/json',
-        'Authorization': os.env.REMOTE_LOGGING_AUTHORIZATION || ''
+        // The authorization is given to the bridge by the keys of its Secret, prefixed in its environment
+        'Authorization': (remoteLogging?.AuthorizationKey && os.env['REMOTE_LOGGING_AUTHORIZATION_' + remoteLogging.AuthorizationKey]) ||
+          os.env.REMOTE_LOGGING_AUTHORIZATION || ''
       }
     }).log,
-    {
-      toInt63,
-    } = pipy.solve('utils.js'),
     initTracingHeaders = (headers) => (
       (
         uuid = algo.uuid(),
@@ -46,6 +50,11 @@
         headers['ecnet-stats-pod'] = pod
       )
     )(),
+
+    // Keeps only the selected top level fields of the access log
+    selectFields = (loggingData) => (
+      fields ? Object.fromEntries(fields.filter(f => f in loggingData).map(f => [f, loggingData[f]])) : loggingData
+    ),
   ) => (
     {
       loggingEnabled: Boolean(logLogging),
@@ -58,7 +67,7 @@
             !msg.head.headers['x-b3-traceid'] && (
               initTracingHeaders(msg.head.headers)
             ),
-            sampled = (!tracingLimitedID || toInt63(msg.head.headers['x-b3-traceid']) < tracingLimitedID)
+            sampled = isTraceSampled(msg.head.headers['x-b3-traceid'], sampledFraction)
           ),
           sampled ? (
             {
@@ -99,7 +108,7 @@
         loggingData['resTime'] = Date.now(),
         loggingData['endTime'] = Date.now(),
         loggingData['type'] = type,
-        logLogging(loggingData)
+        logLogging(selectFields(loggingData))
         // , console.log('loggingData : ', loggingData)
       ),
     }
@@ -1,7 +1,9 @@
 ((
   config = pipy.solve('config.js'),
-  probeScheme = config?.Spec?.Probes?.LivenessProbes?.[0]?.httpGet?.scheme,
   _ = pipy.exec(['sh', '-c', 'while [ "$(ip addr show dev ' + (os.env.CNI_BRIDGE_ETH || 'cni0') + ' 2>&1 | grep inet > /dev/null; echo $?)" -ne 0 ]; do sleep 0.1; done;']),
+  proxyPort = config?.Spec?.Bridge?.ProxyPort || 15001,
+  udpProxyPort = config?.Spec?.Bridge?.UDPProxyPort || 15002,
+  dnsProxyPort = config?.Spec?.Bridge?.DNSProxyPort || 15053,
   bridgeIP = pipy.exec('ip addr show dev ' + (os.env.CNI_BRIDGE_ETH || 'cni0')).toString().split('\n').find(s => s.trim().startsWith('inet'))?.trim?.()?.split?.(' ')?.[1]?.split?.('/')?.[0] || '0.0.0.0',
 ) => pipy()
 
@@ -17,19 +19,28 @@
 .branch(
   Boolean(config?.Outbound || config?.Spec?.Traffic?.EnableEgress), (
     $=>$
-    .listen(bridgeIP + ':15001', { transparent: true })
+    .listen(bridgeIP + ':' + proxyPort, { transparent: true })
     .onStart(() => new Data)
     .use('modules/outbound-main.js')
   )
 )
 
-.listen(probeScheme ? 15901 : 0)
+.branch(
+  Object.values(config?.Outbound?.TrafficMatches || {}).some(matches => matches.some(match => match.Protocol === 'udp')), (
+    $=>$
+    .listen(bridgeIP + ':' + udpProxyPort, { protocol: 'udp', transparent: true })
+    .use('modules/outbound-udp-main.js')
+  )
+)
+
+// The probes are served from the boot, the readiness reports not ready until the config is synced
+.listen(15901)
 .use('probes.js', 'liveness')
 
-.listen(probeScheme ? 15902 : 0)
+.listen(15902)
 .use('probes.js', 'readiness')
 
-.listen(probeScheme ? 15903 : 0)
+.listen(15903)
 .use('probes.js', 'startup')
 
 .listen(15010)
@@ -44,7 +55,7 @@
 .branch(
   true, (
     $=>$
-    .listen(bridgeIP + ':15053', { protocol: 'udp', transparent: true } )
+    .listen(bridgeIP + ':' + dnsProxyPort, { protocol: 'udp', transparent: true } )
     .chain(['dns-main.js'])
   )
 )
@@ -71,6 +71,20 @@
       'sidecar_response_code_class'
     ]),
 
+    upstreamGrpcRequestCount = new stats.Counter('sidecar_cluster_upstream_grpc_rq', [
+      'sidecar_cluster_name',
+      'grpc_service',
+      'grpc_method',
+      'grpc_status'
+    ]),
+    upstreamGrpcRequestDurationHist = new stats.Histogram('sidecar_cluster_upstream_grpc_rq_time_ms', [
+      5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, Infinity
+    ], [
+      'sidecar_cluster_name',
+      'grpc_service',
+      'grpc_method'
+    ]),
+
     ecnetRequestDurationHist = new stats.Histogram('ecnet_request_duration_ms', [
       5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 300000, 600000, 1800000, 3600000, Infinity
     ], [
@@ -102,6 +116,8 @@
         upstreamCodeXCount: upstreamCodeXCount.withLabels(clusterName),
         upstreamResponseTotal: upstreamResponseTotal.withLabels(namespace, kind, name, pod, clusterName),
         upstreamResponseCode: upstreamResponseCode.withLabels(namespace, kind, name, pod, clusterName),
+        upstreamGrpcRequestCount: upstreamGrpcRequestCount.withLabels(clusterName),
+        upstreamGrpcRequestDurationHist: upstreamGrpcRequestDurationHist.withLabels(clusterName),
       }
     )),
 
@@ -1,8 +1,5 @@
 ((
-  { initRateLimit } = pipy.solve('utils.js'),
-  { rateLimitCounter } = pipy.solve('metrics.js'),
-  rateLimitedCounter = rateLimitCounter.withLabels('throttle-route'),
-  rateLimitCache = new algo.Cache(initRateLimit),
+  throttle = pipy.solve('throttle.js')('throttle-route'),
 ) => (
 
 pipy({
@@ -16,7 +13,7 @@ pipy({
 
 .pipeline()
 .branch(
-  () => _rateLimit = rateLimitCache.get(__route?.RateLimit), (
+  () => _rateLimit = throttle.get(__route?.RateLimit), (
       $=>$
       .branch(
         () => _rateLimit.backlog > 0, (
@@ -29,10 +26,7 @@ pipy({
                 $=>$
                 .replaceData()
                 .replaceMessage(
-                  () => (
-                    rateLimitedCounter.increase(),
-                    [_rateLimit.response, new StreamEnd]
-                  )
+                  () => throttle.reject(_rateLimit)
                 )
               ), (
                 $=>$
@@ -43,12 +37,7 @@ pipy({
           )
         ), (
           $=>$.replaceMessage(
-            msg => (
-              _rateLimit.quota.consume(1) !== 1 ? (
-                rateLimitedCounter.increase(),
-                [_rateLimit.response, new StreamEnd]
-              ) : msg
-            )
+            msg => throttle.consume(_rateLimit) ? msg : throttle.reject(_rateLimit)
           )
         )
       )
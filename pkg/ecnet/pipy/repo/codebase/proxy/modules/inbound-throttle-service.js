@@ -1,8 +1,5 @@
 ((
-  { initRateLimit } = pipy.solve('utils.js'),
-  { rateLimitCounter } = pipy.solve('metrics.js'),
-  rateLimitedCounter = rateLimitCounter.withLabels('throttle-service'),
-  rateLimitCache = new algo.Cache(initRateLimit),
+  throttle = pipy.solve('throttle.js')('throttle-service'),
 ) => (
 
 pipy({
@@ -12,11 +9,13 @@ pipy({
 
 .import({
   __service: 'inbound-http-routing',
+  __route: 'inbound-http-routing',
 })
 
 .pipeline()
 .branch(
-  () => _rateLimit = rateLimitCache.get(__service?.RateLimit), (
+  // The rate limit of the matching route replaces the one of the service
+  () => _rateLimit = !__route?.RateLimit && throttle.get(__service?.RateLimit), (
       $=>$
       .branch(
         () => _rateLimit.backlog > 0, (
@@ -29,10 +28,7 @@ pipy({
                 $=>$
                 .replaceData()
                 .replaceMessage(
-                  () => (
-                    rateLimitedCounter.increase(),
-                    [_rateLimit.response, new StreamEnd]
-                  )
+                  () => throttle.reject(_rateLimit)
                 )
               ), (
                 $=>$
@@ -43,12 +39,7 @@ pipy({
           )
         ), (
           $=>$.replaceMessage(
-            msg => (
-              _rateLimit.quota.consume(1) !== 1 ? (
-                rateLimitedCounter.increase(),
-                [_rateLimit.response, new StreamEnd]
-              ) : msg
-            )
+            msg => throttle.consume(_rateLimit) ? msg : throttle.reject(_rateLimit)
           )
         )
       )
@@ -1,6 +1,7 @@
 ((
   {
     tracingEnabled,
+    extractTracingHeaders,
     makeZipKinData,
     saveTracing,
   } = pipy.solve('tracing.js'),
@@ -23,6 +24,7 @@ pipy({
 .handleMessage(
   (msg) => (
     tracingEnabled && (
+      extractTracingHeaders(msg.head.headers),
       (_sampled = (msg?.head?.headers?.['x-b3-sampled'] === '1')) && (
         _httpBytesStruct = {},
         _httpBytesStruct.requestSize = msg?.body?.size,
@@ -6,6 +6,7 @@
   {
     shuffle,
     failover,
+    grpcStatus,
   } = pipy.solve('utils.js'),
 
   retryCounter = new stats.Counter('sidecar_cluster_upstream_rq_retry', ['sidecar_cluster_name']),
@@ -64,9 +65,9 @@
 
   clusterConfigs = new algo.Cache(makeClusterConfig),
 
-  shouldRetry = (statusCode) => (
-    _clusterConfig.retryStatusCodes[statusCode] ? (
-      (_retryCount < _clusterConfig.numRetries) ? (
+  shouldRetry = (retryable, numRetries) => (
+    retryable ? (
+      (_retryCount < numRetries) ? (
         _clusterConfig.retryCounter.increase(),
         _clusterConfig.retryBackoffCounter.increase(),
         _retryCount++,
@@ -80,9 +81,16 @@
       false
     )
   ),
+
+  // A grpc status is known from the response head only for trailers-only responses and http errors,
+  // the responses carrying messages have their status in the trailers and are never retried
+  isGrpcRetryable = (head, retryOn) => (
+    (head?.headers?.['grpc-status'] !== undefined || head?.status != 200) && retryOn.includes(grpcStatus(head))
+  ),
 ) => pipy({
   _retryCount: 0,
   _clusterConfig: null,
+  _grpcRetryPolicy: null,
   _failoverObject: null,
   _targetObject: null,
   _muxHttpOptions: null,
@@ -105,6 +113,7 @@
   () => void (
     (_clusterConfig = clusterConfigs.get(__cluster)) && (
       _muxHttpOptions = _clusterConfig.muxHttpOptions,
+      _grpcRetryPolicy = __route?.GRPC?.RetryPolicy,
       _clusterConfig.failoverBalancer && (
         _failoverObject = _clusterConfig.failoverBalancer.next()
       )
@@ -130,6 +139,22 @@
 )
 
 .branch(
+  // Retries are decided on the response head so that streaming responses are not buffered
+  () => _grpcRetryPolicy, (
+    $=>$
+    .replay({
+        delay: () => _grpcRetryPolicy.RetryBackoffBaseInterval * Math.min(10, Math.pow(2, _retryCount-1)|0)
+    }).to(
+      $=>$
+      .link('upstream')
+      .replaceMessageStart(
+        msg => (
+          shouldRetry(isGrpcRetryable(msg.head, _grpcRetryPolicy.RetryOn), _grpcRetryPolicy.NumRetries) ? new StreamEnd('Replay') : msg
+        )
+      )
+    )
+  ),
+
   () => _clusterConfig?.needRetry, (
     $=>$
     .replay({
@@ -139,7 +164,7 @@
       .link('upstream')
       .replaceMessageStart(
         msg => (
-          shouldRetry(msg.head.status) ? new StreamEnd('Replay') : msg
+          shouldRetry(_clusterConfig.retryStatusCodes[msg.head.status], _clusterConfig.numRetries) ? new StreamEnd('Replay') : msg
         )
       )
     )
@@ -7,6 +7,21 @@
 
   allMethods = ['GET', 'HEAD', 'POST', 'PUT', 'DELETE', 'PATCH', 'OPTIONS'],
 
+  // https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md
+  grpcTimeoutUnits = { 'H': 3600, 'M': 60, 'S': 1, 'm': 0.001, 'u': 0.000001, 'n': 0.000000001 },
+
+  // Sets the deadline of the route unless the client asked for a shorter one
+  setGrpcTimeout = (headers, timeout) => (
+    (
+      deadline = headers['grpc-timeout'],
+      current = deadline ? (deadline.substring(0, deadline.length - 1) | 0) * (grpcTimeoutUnits[deadline.charAt(deadline.length - 1)] || 0) : 0,
+    ) => (
+      (!current || current > timeout) && (
+        headers['grpc-timeout'] = Math.ceil(timeout * 1000) + 'm'
+      )
+    )
+  )(),
+
   clusterCache = new algo.Cache(
     (clusterName => (
       (cluster = config?.Outbound?.ClustersConfigs?.[clusterName]) => (
@@ -144,6 +159,9 @@
           true
         ) || (
           portHandlers.get(__port)(msg)
+        ),
+        __route?.GRPC?.Timeout > 0 && (
+          setGrpcTimeout(msg.head.headers, __route.GRPC.Timeout)
         )
       )
     )
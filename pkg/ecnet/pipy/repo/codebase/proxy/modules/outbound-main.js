@@ -10,7 +10,7 @@
 
   makePortHandler = (port) => (
     (
-      destinations = (config?.Outbound?.TrafficMatches?.[port] || []).map(
+      destinations = (config?.Outbound?.TrafficMatches?.[port] || []).filter(config => config.Protocol !== 'udp').map(
         config => ({
           ranges: config.DestinationIPRanges && Object.entries(config.DestinationIPRanges).map(
             ([k, config]) => ({
@@ -81,6 +81,8 @@
       'modules/outbound-metrics-http.js',
       'modules/outbound-tracing-http.js',
       'modules/outbound-logging-http.js',
+      'modules/outbound-throttle-service.js',
+      'modules/outbound-throttle-route.js',
       'modules/outbound-circuit-breaker.js',
       'modules/outbound-http-load-balancing.js',
       'modules/outbound-http-default.js',
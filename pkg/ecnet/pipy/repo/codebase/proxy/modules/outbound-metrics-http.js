@@ -3,10 +3,16 @@
     metricsCache,
     identityCache,
   } = pipy.solve('metrics.js'),
+  {
+    grpcStatus,
+  } = pipy.solve('utils.js'),
 ) => (
 
 pipy({
-  _requestTime: null
+  _requestTime: null,
+  _grpcService: null,
+  _grpcMethod: null,
+  _grpcResponseHead: null,
 })
 
 .import({
@@ -15,8 +21,16 @@ pipy({
 
 .pipeline()
 .handleMessageStart(
-  () => (
-    _requestTime = Date.now()
+  (msg) => (
+    _requestTime = Date.now(),
+    msg?.head?.headers?.['content-type']?.startsWith?.('application/grpc') && (
+      (
+        path = msg.head.path.split('/'),
+      ) => (
+        _grpcService = path[1] || '',
+        _grpcMethod = path[2] || ''
+      )
+    )()
   )
 )
 .chain()
@@ -39,9 +53,25 @@ pipy({
         metrics.upstreamCodeCount.withLabels(status).increase(),
         metrics.upstreamCodeXCount.withLabels(statusClass).increase(),
         metrics.upstreamResponseCode.withLabels(statusClass).increase()
+      ),
+      _grpcService !== null && (
+        _grpcResponseHead = msg?.head || {}
       )
     )
   )()
 )
+.handleMessageEnd(
+  (msgEnd) => (
+    _grpcResponseHead && (
+      (
+        metrics = metricsCache.get(__cluster?.name),
+      ) => (
+        metrics.upstreamGrpcRequestCount.withLabels(_grpcService, _grpcMethod, grpcStatus(_grpcResponseHead, msgEnd?.tail)).increase(),
+        metrics.upstreamGrpcRequestDurationHist.withLabels(_grpcService, _grpcMethod).observe(Date.now() - _requestTime),
+        _grpcResponseHead = null
+      )
+    )()
+  )
+)
 
 ))()
\ No newline at end of file
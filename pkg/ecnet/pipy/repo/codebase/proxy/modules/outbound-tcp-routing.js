@@ -10,6 +10,17 @@
   ),
 
   clusterBalancers = new algo.Cache(cluster => new algo.RoundRobinLoadBalancer(cluster || {})),
+
+  // Finds the service of a TLS connection by SNI, falls back to the only service of the port when no SNI matches
+  tlsServiceName = (port, serverName) => (
+    port?.TlsServerName2Service?.[serverName] || (
+      (
+        serviceNames = Object.keys(port?.TlsServiceRouteRules || {}),
+      ) => (
+        serviceNames.length === 1 ? serviceNames[0] : null
+      )
+    )()
+  ),
 ) => pipy({
   _clusterName: null,
 })
@@ -23,10 +34,26 @@
 })
 
 .pipeline()
-.handleStreamStart(
-  () => (
-    (_clusterName = clusterBalancers.get(__port?.TcpServiceRouteRules?.TargetClusters)?.next?.()?.id) && (
-      __cluster = clusterCache.get(_clusterName)
+.branch(
+  () => __port?.Protocol === 'https', (
+    $=>$.handleTLSClientHello(
+      hello => (
+        (
+          serviceName = tlsServiceName(__port, hello?.serverNames?.[0]),
+        ) => (
+          serviceName && (_clusterName = clusterBalancers.get(__port.TlsServiceRouteRules[serviceName]?.TargetClusters)?.next?.()?.id) && (
+            __cluster = clusterCache.get(_clusterName)
+          )
+        )
+      )()
+    )
+  ), (
+    $=>$.handleStreamStart(
+      () => (
+        (_clusterName = clusterBalancers.get(__port?.TcpServiceRouteRules?.TargetClusters)?.next?.()?.id) && (
+          __cluster = clusterCache.get(_clusterName)
+        )
+      )
     )
   )
 )
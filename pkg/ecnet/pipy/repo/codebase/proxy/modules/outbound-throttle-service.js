@@ -0,0 +1,51 @@
+((
+  throttle = pipy.solve('throttle.js')('outbound-throttle-service'),
+) => (
+
+pipy({
+  _overflow: null,
+  _rateLimit: null,
+})
+
+.import({
+  __service: 'outbound-http-routing',
+  __route: 'outbound-http-routing',
+})
+
+.pipeline()
+.branch(
+  // The rate limit of the matching route replaces the one of the service
+  () => _rateLimit = !__route?.RateLimit && throttle.get(__service?.RateLimit), (
+      $=>$
+      .branch(
+        () => _rateLimit.backlog > 0, (
+          $=>$
+          .muxQueue(() => _rateLimit, () => ({ maxQueue: _rateLimit.backlog })).to(
+            $=>$
+            .onStart((_, n) => void (_overflow = (n > 1)))
+            .branch(
+              () => _overflow, (
+                $=>$
+                .replaceData()
+                .replaceMessage(
+                  () => throttle.reject(_rateLimit)
+                )
+              ), (
+                $=>$
+                .throttleMessageRate(() => _rateLimit.quota)
+                .demuxQueue().to($=>$.chain())
+              )
+            )
+          )
+        ), (
+          $=>$.replaceMessage(
+            msg => throttle.consume(_rateLimit) ? msg : throttle.reject(_rateLimit)
+          )
+        )
+      )
+    ), (
+      $=>$.chain()
+    )
+  )
+
+))()
\ No newline at end of file
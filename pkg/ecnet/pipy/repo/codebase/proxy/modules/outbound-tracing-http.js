@@ -2,6 +2,7 @@
   {
     tracingEnabled,
     initTracingHeaders,
+    propagateTracingHeaders,
     makeZipKinData,
     saveTracing,
   } = pipy.solve('tracing.js'),
@@ -30,6 +31,7 @@ pipy({
         _httpBytesStruct.requestSize = msg?.body?.size,
         _zipkinData = makeZipKinData(msg, msg.head.headers, __cluster?.name, 'CLIENT', false)
       ),
+      propagateTracingHeaders(msg.head.headers),
       _sampled ? sampledCounter1.increase() : sampledCounter0.increase()
     )
   )
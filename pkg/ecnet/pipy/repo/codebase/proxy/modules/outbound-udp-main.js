@@ -0,0 +1,107 @@
+((
+  config = pipy.solve('config.js'),
+  isDebugEnabled = config?.Spec?.SidecarLogLevel === 'debug',
+
+  htons = port => ((port & 0xff) << 8) | (port >> 8),
+
+  ipBytes = ip => ip.split('.').map(b => b | 0),
+
+  // The tc programs redirect the udp datagrams sent to the bridge to this listener, their
+  // original destination is kept in ecnet_udp_nat keyed by the address they are seen from
+  natPair = new CStruct({
+    sip: 'uint8[4]',
+    dip: 'uint8[4]',
+    sport: 'uint16',
+    dport: 'uint16',
+  }),
+  natOrigin = new CStruct({
+    ip: 'uint8[4]',
+    port: 'uint16',
+    sport: 'uint16',
+    lastSeen: 'uint64',
+  }),
+  udpNatMap = (
+    (info = bpf.Map.list().find(m => m.name === 'ecnet_udp_nat')) => (
+      info ? bpf.Map.open(info.id, natPair, natOrigin) : null
+    )
+  )(),
+
+  originalDestination = () => (
+    (
+      origin = udpNatMap?.lookup?.({
+        sip: ipBytes(__inbound.localAddress),
+        dip: ipBytes(__inbound.remoteAddress),
+        sport: htons(__inbound.localPort),
+        dport: htons(__inbound.remotePort),
+      }),
+    ) => (
+      origin ? (
+        { ip: origin.ip.join('.'), port: htons(origin.port) }
+      ) : (
+        { ip: __inbound.destinationAddress, port: __inbound.destinationPort }
+      )
+    )
+  )(),
+
+  // The imports with a virtual IP are told apart by it, the others share the bridge IP
+  portMatchers = new algo.Cache(port => (
+    (
+      matches = (config?.Outbound?.TrafficMatches?.[port] || []).filter(match => match.Protocol === 'udp').map(
+        match => ({
+          masks: match.DestinationIPRanges && Object.keys(match.DestinationIPRanges).map(k => new Netmask(k)),
+          match,
+        })
+      ),
+    ) => (
+      ip => (
+        matches.find(m => m.masks && m.masks.some(mask => mask.contains(ip))) || matches.find(m => !m.masks)
+      )?.match
+    )
+  )()),
+
+  clusterBalancers = new algo.Cache(clusters => new algo.RoundRobinLoadBalancer(clusters || {})),
+
+  targetBalancers = new algo.Cache(clusterName => new algo.RoundRobinLoadBalancer(
+    Object.fromEntries(Object.entries(config?.Outbound?.ClustersConfigs?.[clusterName]?.Endpoints || {}).map(([k, v]) => [k, v.Weight || 100]))
+  )),
+) => pipy({
+  _origin: null,
+  _match: null,
+  _cluster: null,
+  _target: null,
+})
+
+.pipeline()
+.onStart(
+  () => void (
+    _origin = originalDestination(),
+    _match = portMatchers.get(_origin.port)(_origin.ip),
+    _match && (
+      _cluster = clusterBalancers.get(_match.TcpServiceRouteRules?.TargetClusters)?.next?.()?.id
+    ),
+    _cluster && (
+      _target = targetBalancers.get(_cluster)?.next?.()?.id
+    )
+  )
+)
+.branch(
+  isDebugEnabled, (
+    $=>$.handleStreamStart(
+      () => (
+        console.log('outbound-udp # origin/cluster/target :', _origin.ip, _origin.port, _cluster, _target)
+      )
+    )
+  )
+)
+.branch(
+  () => _target, (
+    $=>$.connect(() => _target, { protocol: 'udp' })
+  ),
+  (
+    $=>$.replaceStreamStart(
+      new StreamEnd()
+    )
+  )
+)
+
+)()
@@ -1,88 +1,57 @@
 ((
   config = pipy.solve('config.js'),
-  probeScheme,
-  probeTarget,
-  probePath,
-) => (
-(
-  // Initialize probeScheme, probeTarget, probePath
-  config?.Inbound?.TrafficMatches && Object.entries(config.Inbound.TrafficMatches).map(
-    ([port, match]) => (
-      (match.Protocol === 'http') && (!probeTarget || !match.SourceIPRanges) && (probeTarget = '127.0.0.1:' + port)
-    )
-  ),
-  config?.Spec?.Probes?.LivenessProbes && config.Spec.Probes.LivenessProbes[0]?.httpGet?.port == 15901 &&
-  (probeScheme = config.Spec.Probes.LivenessProbes[0].httpGet.scheme) && !probeTarget &&
-  ((probeScheme === 'HTTP' && (probeTarget = '127.0.0.1:80')) || (probeScheme === 'HTTPS' && (probeTarget = '127.0.0.1:443'))) &&
-  (probePath = '/')
-),
 
-pipy()
+  // The version of the config published by the controller, absent in the embedded one
+  configVersion = config?.Version,
+
+  // The codebase the bridge is running, ex. http://ecnet-controller.ecnet-system:6060/repo/ecnet/proxy.bridge.ecnet/
+  repo = (os.env.ECNET_PROXY_REPO || '').replace(/^http:\/\//, ''),
+  repoAddress = repo.split('/')[0],
+  repoConfigPath = repo.substring(repoAddress.length).replace(/\/?$/, '/') + 'config.json',
+
+  response = ok => new Message(
+    { status: ok ? 200 : 503 },
+    ok ? 'OK' : 'Service Unavailable'
+  ),
+) => pipy()
 
 .pipeline('liveness')
-.branch(
-  () => probeScheme === 'HTTP', $ => $
-    .demuxHTTP().to($ => $
-      .handleMessageStart(
-        msg => (
-          msg.head.path === '/ecnet-liveness-probe' && (msg.head.path = '/liveness'),
-          probePath && (msg.head.path = probePath)
-        )
-      )
-      .muxHTTP(() => probeTarget).to($ => $
-        .connect(() => probeTarget)
-      )
-    ),
-  () => Boolean(probeTarget), $ => $
-    .connect(() => probeTarget),
-  $ => $
-    .replaceStreamStart(
-      new StreamEnd('ConnectionReset')
-    )
+.demuxHTTP().to($ => $
+  .replaceMessage(
+    () => response(true)
+  )
 )
 
+// The bridge has started once it serves the probes, even if the controller is not reachable yet
+.pipeline('startup')
+.demuxHTTP().to($ => $
+  .replaceMessage(
+    () => response(true)
+  )
+)
+
+// Ready only while the loaded config matches the one currently published by the controller,
+// which is checked again on every probe
 .pipeline('readiness')
-.branch(
-  () => probeScheme === 'HTTP', $ => $
-    .demuxHTTP().to($ => $
-      .handleMessageStart(
-        msg => (
-          msg.head.path === '/ecnet-readiness-probe' && (msg.head.path = '/readiness'),
-          probePath && (msg.head.path = probePath)
-        )
+.demuxHTTP().to($ => $
+  .branch(
+    () => !repoAddress || !configVersion, $ => $
+      .replaceMessage(
+        () => response(Boolean(configVersion))
+      ),
+    $ => $
+      .replaceMessage(
+        () => new Message({ method: 'GET', path: repoConfigPath, headers: { host: repoAddress } })
       )
-      .muxHTTP(() => probeTarget).to($ => $
-        .connect(() => probeTarget)
+      .muxHTTP(() => repoAddress).to($ => $
+        .connect(() => repoAddress)
       )
-    ),
-  () => Boolean(probeTarget), $ => $
-    .connect(() => probeTarget),
-  $ => $
-    .replaceStreamStart(
-      new StreamEnd('ConnectionReset')
-    )
-)
-
-.pipeline('startup')
-.branch(
-  () => probeScheme === 'HTTP', $ => $
-    .demuxHTTP().to($ => $
-      .handleMessageStart(
-        msg => (
-          msg.head.path === '/ecnet-startup-probe' && (msg.head.path = '/startup'),
-          probePath && (msg.head.path = probePath)
+      .replaceMessage(
+        msg => response(
+          (msg?.head?.status === 200) && (JSON.decode(msg.body)?.Version === configVersion)
         )
       )
-      .muxHTTP(() => probeTarget).to($ => $
-        .connect(() => probeTarget)
-      )
-    ),
-  () => Boolean(probeTarget), $ => $
-    .connect(() => probeTarget),
-  $ => $
-    .replaceStreamStart(
-      new StreamEnd('ConnectionReset')
-    )
+  )
 )
 
-))()
\ No newline at end of file
+)()
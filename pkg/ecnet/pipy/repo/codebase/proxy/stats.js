@@ -70,7 +70,7 @@ pipy({
     () => (_statsPath === '/listeners'), $ => $
       .replaceMessage(
         (msg) => (
-          ((config?.Outbound || config?.Spec?.Traffic?.EnableEgress) && (msg = 'outbound-listener::0.0.0.0:15001\n')) || (msg = ''),
+          ((config?.Outbound || config?.Spec?.Traffic?.EnableEgress) && (msg = 'outbound-listener::0.0.0.0:' + (config?.Spec?.Bridge?.ProxyPort || 15001) + '\n')) || (msg = ''),
           (config?.Inbound?.TrafficMatches) && (msg += 'inbound-listener::0.0.0.0:15003\n'),
           msg += 'inbound-prometheus-listener::0.0.0.0:15010\n',
           new Message(msg)
@@ -92,6 +92,10 @@ pipy({
       .replaceMessage(
         msg => http.File.from('config.json').toMessage(msg.head.headers['accept-encoding'])
       ),
+    () => (_statsPath === '/manifest'), $ => $
+      .replaceMessage(
+        msg => http.File.from('manifest.json').toMessage(msg.head.headers['accept-encoding'])
+      ),
     $ => $
       .replaceMessage(
         new Message({
@@ -0,0 +1,44 @@
+((
+  { rateLimitCounter } = pipy.solve('metrics.js'),
+
+  initRateLimit = rateLimit => (
+    rateLimit?.Local ? (
+      {
+        backlog: rateLimit.Local.Backlog || 0,
+        quota: new algo.Quota(
+          rateLimit.Local.Burst || rateLimit.Local.Requests || 0,
+          {
+            produce: rateLimit.Local.Requests || 0,
+            per: rateLimit.Local.StatTimeWindow || 0,
+          }
+        ),
+        response: new Message({
+          status: rateLimit.Local.ResponseStatusCode || 429,
+          headers: Object.fromEntries((rateLimit.Local.ResponseHeadersToAdd || []).map(({ Name, Value }) => [Name, Value])),
+        }),
+      }
+    ) : null
+  ),
+) => (
+  // The token buckets are shared by all the throttle modules, the rejections are counted by the label of each module
+  (
+    rateLimitCache = new algo.Cache(initRateLimit),
+  ) => (
+    label => (
+      (
+        rateLimitedCounter = rateLimitCounter.withLabels(label),
+      ) => (
+        {
+          get: rateLimit => rateLimitCache.get(rateLimit),
+
+          consume: bucket => bucket.quota.consume(1) === 1,
+
+          reject: bucket => (
+            rateLimitedCounter.increase(),
+            [bucket.response, new StreamEnd]
+          ),
+        }
+      )
+    )()
+  )
+)())()
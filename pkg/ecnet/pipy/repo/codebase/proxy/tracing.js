@@ -5,10 +5,18 @@
       kind,
       name,
       pod,
+      toSampledFraction,
+      isTraceSampled,
     } = pipy.solve('utils.js'),
-    tracingAddress = os.env.TRACING_ADDRESS,
-    tracingEndpoint = (os.env.TRACING_ENDPOINT || '/api/v2/spans'),
-    tracingLimitedID = os.env.TRACING_SAMPLED_FRACTION && (os.env.TRACING_SAMPLED_FRACTION * Math.pow(2, 63)),
+    config = pipy.solve('config.js'),
+    tracing = config?.Spec?.Tracing,
+    tracingAddress = tracing?.Address || os.env.TRACING_ADDRESS,
+    tracingEndpoint = (tracing?.Endpoint || os.env.TRACING_ENDPOINT || '/api/v2/spans'),
+    sampledFraction = toSampledFraction(tracing ? tracing.SampledFraction : os.env.TRACING_SAMPLED_FRACTION),
+    propagation = (tracing?.Propagation || 'b3+w3c').split('+'),
+    propagateB3 = propagation.includes('b3'),
+    propagateW3C = propagation.includes('w3c'),
+    padHex = (str, len) => ('0'.repeat(len) + str).slice(-len),
     logZipkin = tracingAddress && new logging.JSONLogger('zipkin').toHTTP('http://' + tracingAddress + tracingEndpoint, {
       batch: {
         timeout: 1,
@@ -22,19 +30,45 @@
         'Content-Type': 'application/json',
       }
     }).log,
-    {
-      toInt63,
-    } = pipy.solve('utils.js'),
+
+    // Accepts the W3C trace context from the peer cluster as the B3 one
+    extractTracingHeaders = (headers) => (
+      (
+        traceparent = !headers['x-b3-traceid'] && headers['traceparent']?.split?.('-'),
+      ) => (
+        traceparent?.length === 4 && traceparent[1].length === 32 && traceparent[2].length === 16 && (
+          headers['x-b3-traceid'] = traceparent[1],
+          headers['x-b3-spanid'] = traceparent[2],
+          !headers['x-b3-sampled'] && (headers['x-b3-sampled'] = (parseInt(traceparent[3], 16) & 1) ? '1' : '0')
+        )
+      )
+    )(),
   ) => (
     {
       tracingEnabled: Boolean(logZipkin),
 
+      extractTracingHeaders,
+
+      // Injects the trace context in the formats expected by the peer cluster
+      propagateTracingHeaders: (headers) => (
+        headers['x-b3-traceid'] && propagateW3C && (
+          headers['traceparent'] = '00-' + padHex(headers['x-b3-traceid'], 32) + '-' + padHex(headers['x-b3-spanid'], 16) + '-' + (headers['x-b3-sampled'] === '1' ? '01' : '00')
+        ),
+        !propagateB3 && (
+          delete headers['x-b3-traceid'],
+          delete headers['x-b3-spanid'],
+          delete headers['x-b3-parentspanid'],
+          delete headers['x-b3-sampled']
+        )
+      ),
+
       initTracingHeaders: (headers, proto) => (
         (
           sampled = true,
           uuid = algo.uuid(),
           id = uuid.substring(0, 18).replaceAll('-', ''),
         ) => (
+          extractTracingHeaders(headers),
           proto && (headers['x-forwarded-proto'] = proto),
           headers['x-b3-spanid'] && (
             (headers['x-b3-parentspanid'] = headers['x-b3-spanid']) && (headers['x-b3-spanid'] = id)
@@ -45,7 +79,7 @@
           headers['x-b3-sampled'] && (
             sampled = (headers['x-b3-sampled'] === '1'), true
           ) || (
-            (sampled = (!tracingLimitedID || toInt63(headers['x-b3-traceid']) < tracingLimitedID)) ? (headers['x-b3-sampled'] = '1') : (headers['x-b3-sampled'] = '0')
+            (sampled = isTraceSampled(headers['x-b3-traceid'], sampledFraction)) ? (headers['x-b3-sampled'] = '1') : (headers['x-b3-sampled'] = '0')
           ),
           !headers['x-request-id'] && (
             headers['x-request-id'] = uuid
@@ -10,6 +10,19 @@
     ) => value / 2
   )(),
   traceId = () => algo.uuid().substring(0, 18).replaceAll('-', ''),
+
+  // The sampled fraction clamped to [0, 1], every request is sampled when it is not configured
+  toSampledFraction = fraction => (
+    (fraction === undefined || fraction === null || fraction === '') ? 1 : Math.min(Math.max(Number(fraction) || 0, 0), 1)
+  ),
+
+  // Whether the trace is sampled, by the last 64 bits of its id: always at 1 and never at 0
+  isTraceSampled = (id, fraction) => (
+    fraction >= 1 || (fraction > 0 && toInt63(id.slice(-16)) < fraction * Math.pow(2, 63))
+  ),
+
+  // https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md
+  grpcStatusByHTTPStatus = { 400: 13, 401: 16, 403: 7, 404: 12, 429: 14, 502: 14, 503: 14, 504: 14 },
 ) => (
   {
     namespace,
@@ -17,25 +30,6 @@
     name,
     pod,
 
-    initRateLimit: rateLimit => (
-      rateLimit?.Local ? (
-        {
-          backlog: rateLimit.Local.Backlog || 0,
-          quota: new algo.Quota(
-            rateLimit.Local.Burst || rateLimit.Local.Requests || 0,
-            {
-              produce: rateLimit.Local.Requests || 0,
-              per: rateLimit.Local.StatTimeWindow || 0,
-            }
-          ),
-          response: new Message({
-            status: rateLimit.Local.ResponseStatusCode || 429,
-            headers: Object.fromEntries((rateLimit.Local.ResponseHeadersToAdd || []).map(({ Name, Value }) => [Name, Value])),
-          }),
-        }
-      ) : null
-    ),
-
     shuffle: arg => (
       (
         sort = a => (a.map(e => e).map(() => a.splice(Math.random() * a.length | 0, 1)[0])),
@@ -57,7 +51,20 @@
       ))() : null
     ),
 
+    grpcStatus: (head, tail) => (
+      (
+        status = tail?.headers?.['grpc-status'] ?? head?.headers?.['grpc-status'],
+        httpStatus = head?.status,
+      ) => (
+        (status !== undefined) ? (status | 0) : (
+          httpStatus ? (grpcStatusByHTTPStatus[httpStatus] ?? 2) : 14
+        )
+      )
+    )(),
+
     toInt63,
     traceId,
+    toSampledFraction,
+    isTraceSampled,
   }
 ))()
\ No newline at end of file
@@ -38,6 +38,7 @@ func (s *Server) broadcastListener() {
 			reconfirm = true
 
 		case <-slidingTimer.C:
+			s.syncCodebaseOverlays()
 			connectedProxies := s.fireExistProxies()
 			if len(connectedProxies) > 0 {
 				for _, proxy := range connectedProxies {
@@ -7,10 +7,12 @@ import (
 	"time"
 
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/catalog"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/metricsstore"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/client"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/codebase"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/util"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/proxyserver"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
 )
 
 // PipyConfGeneratorJob is the job to generate pipy policy json
@@ -34,6 +36,11 @@ func (job *PipyConfGeneratorJob) Run() {
 		return
 	}
 
+	startTime := time.Now()
+	defer func() {
+		metricsstore.DefaultMetricsStore.ProxyConfigUpdateTime.Observe(time.Since(startTime).Seconds())
+	}()
+
 	s := job.repoServer
 	proxy := job.proxy
 
@@ -69,13 +76,7 @@ func reorder(pipyConf *PipyConf) {
 
 func outbound(cataloger catalog.MeshCataloger, s *Server, pipyConf *PipyConf, proxy *proxyserver.Proxy) bool {
 	outboundTrafficPolicy := cataloger.GetOutboundMeshTrafficPolicy()
-	if len(outboundTrafficPolicy.ServicesResolvableSet) > 0 {
-		pipyConf.DNSResolveDB = outboundTrafficPolicy.ServicesResolvableSet
-		//pipyConf.DNSResolveDB = make(map[string][]string)
-		//for k := range outboundTrafficPolicy.ServicesResolvableSet {
-		//	pipyConf.DNSResolveDB[k] = []string{"10.244.2.1"}
-		//}
-	}
+	pipyConf.setDNSResolveDB(outboundTrafficPolicy.ServicesResolvableSet)
 	outboundDependClusters := generatePipyOutboundTrafficRoutePolicy(pipyConf, outboundTrafficPolicy)
 	if len(outboundDependClusters) > 0 {
 		if ready := generatePipyOutboundTrafficBalancePolicy(cataloger, pipyConf, outboundTrafficPolicy, outboundDependClusters); !ready {
@@ -90,7 +91,8 @@ func outbound(cataloger catalog.MeshCataloger, s *Server, pipyConf *PipyConf, pr
 
 func plugin(s *Server, pipyConf *PipyConf) (pluginSetVersion string) {
 	pipyConf.Chains = nil
-	setSidecarChain(s.cfg, pipyConf)
+	customPlugins := setSidecarChain(s.cfg, pipyConf)
+	pluginSetVersion = s.updatePlugins(customPlugins)
 	return
 }
 
@@ -99,7 +101,33 @@ func features(s *Server, proxy *proxyserver.Proxy, pipyConf *PipyConf) {
 		meshConf := mc.GetConfigurator()
 		proxy.MeshConf = meshConf
 		pipyConf.setSidecarLogLevel((*meshConf).GetEcnetConfig().Spec.Sidecar.LogLevel)
-		pipyConf.setLocalDNSProxy((*meshConf).LocalDNSProxyEnabled(), (*meshConf).GetLocalDNSProxyPrimaryUpstream(), (*meshConf).GetLocalDNSProxySecondaryUpstream())
+		pipyConf.setProbes()
+		pipyConf.setLocalDNSProxy((*meshConf).LocalDNSProxyEnabled(), (*meshConf).GetLocalDNSProxyPrimaryUpstream(), (*meshConf).GetLocalDNSProxySecondaryUpstream(),
+			(*meshConf).GetLocalDNSProxyTTL(), (*meshConf).GetLocalDNSProxyNegativeTTL(), getDNSSearchDomains(mc.GetTrustDomain()))
+		pipyConf.setTracing((*meshConf).IsTracingEnabled(), fmt.Sprintf("%s:%d", (*meshConf).GetTracingHost(), (*meshConf).GetTracingPort()),
+			(*meshConf).GetTracingEndpoint(), (*meshConf).GetTracingSampledFraction(), (*meshConf).GetTracingPropagation())
+		// The access logs are not shipped with an authorization the bridges are not given
+		authorizationKey, err := (*meshConf).GetRemoteLoggingAuthorizationKey()
+		if err != nil {
+			log.Error().Err(err).Msg("Rejecting the remote logging config")
+		}
+		pipyConf.setRemoteLogging((*meshConf).IsRemoteLoggingEnabled() && err == nil, fmt.Sprintf("%s:%d", (*meshConf).GetRemoteLoggingHost(), (*meshConf).GetRemoteLoggingPort()),
+			(*meshConf).GetRemoteLoggingEndpoint(), authorizationKey, (*meshConf).GetRemoteLoggingSampledFraction(), (*meshConf).GetRemoteLoggingFields())
+		pipyConf.setClusterSet((*meshConf).GetClusterSetVIPCIDR())
+		pipyConf.setClusterDomain(mc.GetTrustDomain())
+		pipyConf.setBridge((*meshConf).GetBridgeProxyPort(), (*meshConf).GetBridgeUDPProxyPort(),
+			(*meshConf).GetBridgeDNSProxyPort(), (*meshConf).GetBridgeDNSCapturePort())
+	}
+}
+
+// getDNSSearchDomains returns the domains local DNS Proxy appends to the names not found as they are,
+// so that service.namespace resolves to service.namespace.svc.cluster.local and so on
+func getDNSSearchDomains(clusterDomain string) []string {
+	return []string{
+		fmt.Sprintf("svc.%s", clusterDomain),
+		clusterDomain,
+		fmt.Sprintf("svc.%s", service.ClusterSetDomain),
+		service.ClusterSetDomain,
 	}
 }
 
@@ -121,11 +149,19 @@ func (job *PipyConfGeneratorJob) publishSidecarConf(repoClient *client.PipyRepoC
 		bytes = append(bytes, []byte(pluginSetV)...)
 		codebaseCurV := util.Hash(bytes)
 		if codebaseCurV != codebasePreV {
+			if !job.repoServer.isCodebaseCompatible() {
+				log.Error().Str("uid", proxy.UUID.String()).
+					Msgf("Refusing to publish config.json, the proxy codebase loaded by the bridges doesn't understand config schema version %d", codebase.ConfigSchemaVersion)
+				if job.repoServer.retryProxiesJob != nil {
+					job.repoServer.retryProxiesJob()
+				}
+				return
+			}
 			log.Log().Str("uid", proxy.UUID.String()).
 				Str("codebasePreV", fmt.Sprintf("%d", codebasePreV)).
 				Str("codebaseCurV", fmt.Sprintf("%d", codebaseCurV)).
 				Msg("config.json")
-			proxyCodebase := fmt.Sprintf("%s/proxy.bridge.ecnet", ecnetProxyCodebase)
+			proxyCodebase := getProxyCodebase()
 			success, err := repoClient.DeriveCodebase(proxyCodebase, ecnetCodebaseRepo, codebaseCurV-2)
 			if success {
 				ts := time.Now()
@@ -133,6 +169,7 @@ func (job *PipyConfGeneratorJob) publishSidecarConf(repoClient *client.PipyRepoC
 				version := fmt.Sprintf("%d", codebaseCurV)
 				pipyConf.Version = &version
 				bytes, _ = json.MarshalIndent(pipyConf, "", " ")
+				metricsstore.DefaultMetricsStore.ProxyConfigSize.Observe(float64(len(bytes)))
 				_, err = repoClient.Batch(fmt.Sprintf("%d", codebaseCurV-1), []client.Batch{
 					{
 						Basepath: proxyCodebase,
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	mapset "github.com/deckarep/golang-set"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/errcode"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/client"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/codebase"
+)
+
+// codebaseOverlays collects the overlays from the codebase overlay ConfigMaps,
+// any invalid file rejects the overlays as a whole.
+func (s *Server) codebaseOverlays() ([]codebase.Overlay, error) {
+	configMaps := s.kubeController.ListCodebaseOverlays()
+	sort.Slice(configMaps, func(i, j int) bool {
+		return configMaps[i].Name < configMaps[j].Name
+	})
+
+	var overlays []codebase.Overlay
+	for _, cm := range configMaps {
+		source := fmt.Sprintf("%s/%s", cm.Namespace, cm.Name)
+		dir := cm.Annotations[constants.CodebaseOverlayPathAnnotation]
+
+		for key, content := range cm.Data {
+			overlay := codebase.Overlay{
+				Source:   source,
+				Filename: path.Join(dir, key),
+				Content:  []byte(content),
+			}
+			if err := codebase.ValidateOverlay(overlay); err != nil {
+				return nil, fmt.Errorf("invalid codebase overlay ConfigMap %s: %w", source, err)
+			}
+			overlays = append(overlays, overlay)
+		}
+	}
+	return overlays, nil
+}
+
+// mergeCodebaseOverlays returns the static resources of proxy codebase merged with the valid overlays,
+// the overlays and the version of the overlays.
+func (s *Server) mergeCodebaseOverlays() ([]client.BatchItem, []codebase.Overlay, uint64, error) {
+	overlays, err := s.codebaseOverlays()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	items, version, err := codebase.MergeOverlays(overlays)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return items, overlays, version, nil
+}
+
+// uploadBaseCodebase uploads the static resources of proxy codebase merged with the overlays
+// to the base codebase, the upload is skipped unless forced or the overlays have changed.
+// Returns the overlay files to update in the derived codebases, nil if the base codebase hasn't been updated.
+func (s *Server) uploadBaseCodebase(force bool) ([]client.BatchItem, error) {
+	items, overlays, version, err := s.mergeCodebaseOverlays()
+	if err != nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrInvalidCodebaseOverlay)).
+			Msgf("Rejecting codebase overlays, keeping version %d", s.overlayVersion)
+		if !force {
+			return nil, nil
+		}
+		items, overlays, version = codebase.EcnetCodebaseItems, nil, 0
+	}
+
+	if !force && version == s.overlayVersion {
+		return nil, nil
+	}
+
+	overlayFiles := mapset.NewSet()
+	overlayItems := make([]client.BatchItem, 0, len(overlays))
+	for _, overlay := range overlays {
+		overlayFiles.Add(overlay.Filename)
+		overlayItems = append(overlayItems, client.BatchItem{Filename: overlay.Filename, Content: overlay.Content})
+	}
+	for _, filename := range s.overlayFiles.Difference(overlayFiles).ToSlice() {
+		filename := filename.(string)
+		overlayItems = append(overlayItems, client.BatchItem{Obsolete: true, Filename: filename})
+		// The embedded files replaced by the previous overlays are restored by the static resources
+		if !codebase.IsEmbedded(filename) {
+			items = append(items, client.BatchItem{Obsolete: true, Filename: filename})
+		}
+	}
+
+	items = append(items, getManifestItem())
+
+	if _, err = s.repoClient.Batch(fmt.Sprintf("%d", codebase.HashItems(items)), []client.Batch{
+		{
+			Basepath: ecnetCodebase,
+			Items:    items,
+		},
+	}); err != nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrUploadingCodebase)).
+			Msgf("Error uploading codebase %s with overlays version %d", ecnetCodebase, version)
+		return nil, err
+	}
+
+	log.Info().Msgf("Codebase %s uploaded with overlays version %d", ecnetCodebase, version)
+	s.overlayVersion = version
+	s.overlayFiles = overlayFiles
+	return overlayItems, nil
+}
+
+// syncCodebaseOverlays uploads the changed overlays and updates them in place in the proxy codebase,
+// committing the proxy codebase makes the proxy reload its scripts.
+func (s *Server) syncCodebaseOverlays() {
+	overlayItems, err := s.uploadBaseCodebase(false)
+	if err != nil || len(overlayItems) == 0 {
+		return
+	}
+
+	repoLock.Lock()
+	defer repoLock.Unlock()
+
+	proxyCodebase := getProxyCodebase()
+	success, derived, err := s.repoClient.GetCodebase(proxyCodebase)
+	if err != nil || !success {
+		log.Error().Err(err).Msgf("Error getting codebase %s", proxyCodebase)
+		return
+	}
+	if derived == nil {
+		// The proxy codebase is derived from the updated base codebase by the first config generation
+		return
+	}
+
+	if _, err = s.repoClient.Batch(derived.Version, []client.Batch{
+		{
+			Basepath: proxyCodebase,
+			Items:    overlayItems,
+		},
+	}); err != nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrUploadingCodebase)).
+			Msgf("Error updating the overlays of codebase %s", proxyCodebase)
+	}
+}
@@ -3,16 +3,30 @@ package server
 import (
 	"fmt"
 	"sort"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
 
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/configurator"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/client"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/codebase"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/util"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service/policy"
 )
 
-// getPluginURI return the URI of the plugin.
+// getPluginName returns the name of the custom plugin qualified by its namespace.
+func getPluginName(plugin policy.Plugin) string {
+	return fmt.Sprintf("%s/%s", plugin.Namespace, plugin.Name)
+}
+
+// getPluginURI return the URI of the plugin, given its name qualified by its namespace.
 func getPluginURI(name string) string {
 	return fmt.Sprintf("plugins/%s.js", name)
 }
 
-func setSidecarChain(cfg configurator.Configurator, pipyConf *PipyConf) {
+// setSidecarChain sets the plugin chains of sidecar, returns the custom plugins in the chains.
+func setSidecarChain(cfg configurator.Configurator, pipyConf *PipyConf) []policy.Plugin {
+	var customPlugins []policy.Plugin
 	pluginChains := cfg.GetGlobalPluginChains()
 	pipyConf.Chains = make(map[string][]string)
 	for mountPoint, pluginItems := range pluginChains {
@@ -23,11 +37,79 @@ func setSidecarChain(cfg configurator.Configurator, pipyConf *PipyConf) {
 			for _, pluginItem := range pluginItems {
 				if pluginItem.BuildIn {
 					pluginURIs = append(pluginURIs, fmt.Sprintf("%s.js", pluginItem.Name))
-				} else {
-					pluginURIs = append(pluginURIs, getPluginURI(pluginItem.Name))
+					continue
 				}
+				if err := codebase.ValidateOverlay(codebase.Overlay{Filename: getPluginURI(getPluginName(pluginItem)), Content: []byte(pluginItem.Script)}); err != nil {
+					log.Error().Err(err).Msgf("Ignoring plugin %s in chain %s", getPluginName(pluginItem), mountPoint)
+					continue
+				}
+				pluginURIs = append(pluginURIs, getPluginURI(getPluginName(pluginItem)))
+				customPlugins = append(customPlugins, pluginItem)
 			}
 			pipyConf.Chains[mountPoint] = pluginURIs
 		}
 	}
+	return customPlugins
+}
+
+// updatePlugins uploads the scripts of custom plugins to the base codebase and
+// removes the obsolete ones, returns the version of the plugin set.
+func (s *Server) updatePlugins(plugins []policy.Plugin) string {
+	newPluginSet := mapset.NewSet()
+	newPluginNames := mapset.NewSet()
+	var items []client.BatchItem
+	for _, pluginItem := range plugins {
+		pluginName := getPluginName(pluginItem)
+		pluginKey := fmt.Sprintf("%s.%d", pluginName, util.Hash([]byte(pluginItem.Script)))
+		if newPluginSet.Contains(pluginKey) {
+			continue
+		}
+		newPluginSet.Add(pluginKey)
+		newPluginNames.Add(pluginName)
+		if !s.pluginSet.Contains(pluginKey) {
+			items = append(items, client.BatchItem{
+				Filename: getPluginURI(pluginName),
+				Content:  []byte(pluginItem.Script),
+			})
+		}
+	}
+
+	for _, pluginKey := range s.pluginSet.Difference(newPluginSet).ToSlice() {
+		pluginKey := pluginKey.(string)
+		pluginName := pluginKey[:strings.LastIndex(pluginKey, ".")]
+		if !newPluginNames.Contains(pluginName) {
+			items = append(items, client.BatchItem{
+				Obsolete: true,
+				Filename: getPluginURI(pluginName),
+			})
+		}
+	}
+
+	if len(items) > 0 {
+		if _, err := s.repoClient.Batch(fmt.Sprintf("%d", codebase.HashItems(items)), []client.Batch{
+			{
+				Basepath: ecnetCodebase,
+				Items:    items,
+			},
+		}); err != nil {
+			log.Error().Err(err).Msgf("Error uploading plugins to codebase %s", ecnetCodebase)
+			if s.retryProxiesJob != nil {
+				s.retryProxiesJob()
+			}
+			return getPluginSetVersion(s.pluginSet)
+		}
+		s.pluginSet = newPluginSet
+	}
+
+	return getPluginSetVersion(s.pluginSet)
+}
+
+// getPluginSetVersion returns the version of the plugin set.
+func getPluginSetVersion(pluginSet mapset.Set) string {
+	var pluginKeys []string
+	for _, pluginKey := range pluginSet.ToSlice() {
+		pluginKeys = append(pluginKeys, pluginKey.(string))
+	}
+	sort.Strings(pluginKeys)
+	return strings.Join(pluginKeys, ",")
 }
@@ -7,12 +7,43 @@ import (
 	"sort"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
 	multiclusterv1alpha1 "github.com/flomesh-io/ErieCanal/pkg/ecnet/apis/multicluster/v1alpha1"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service/policy"
 )
 
 var (
 	addrWithPort, _ = regexp.Compile(`:\d+$`)
+
+	// uriMatchTypePrecedence defines the order in which the route rules are matched by path match type
+	uriMatchTypePrecedence = map[URIMatchType]int{
+		PathMatchExact:  0,
+		PathMatchPrefix: 1,
+		PathMatchRegex:  2,
+	}
+
+	// grpcStatusCodes maps the names of the grpc status codes to their values
+	grpcStatusCodes = map[string]uint32{
+		"cancelled":           1,
+		"unknown":             2,
+		"invalid-argument":    3,
+		"deadline-exceeded":   4,
+		"not-found":           5,
+		"already-exists":      6,
+		"permission-denied":   7,
+		"resource-exhausted":  8,
+		"failed-precondition": 9,
+		"aborted":             10,
+		"out-of-range":        11,
+		"unimplemented":       12,
+		"internal":            13,
+		"unavailable":         14,
+		"data-loss":           15,
+		"unauthenticated":     16,
+	}
 )
 
 func (p *PipyConf) setSidecarLogLevel(sidecarLogLevel string) (update bool) {
@@ -22,9 +53,32 @@ func (p *PipyConf) setSidecarLogLevel(sidecarLogLevel string) (update bool) {
 	return
 }
 
-func (p *PipyConf) setLocalDNSProxy(enable bool, primary, secondary string) {
+func (p *PipyConf) setProbes() {
+	newProbe := func(port int, path string) []corev1.Probe {
+		return []corev1.Probe{
+			{
+				ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{
+						Path:   path,
+						Port:   intstr.FromInt(port),
+						Scheme: corev1.URISchemeHTTP,
+					},
+				},
+			},
+		}
+	}
+	p.Spec.Probes.LivenessProbes = newProbe(constants.ProxyLivenessProbePort, constants.ProxyLivenessProbePath)
+	p.Spec.Probes.ReadinessProbes = newProbe(constants.ProxyReadinessProbePort, constants.ProxyReadinessProbePath)
+	p.Spec.Probes.StartupProbes = newProbe(constants.ProxyStartupProbePort, constants.ProxyStartupProbePath)
+}
+
+func (p *PipyConf) setLocalDNSProxy(enable bool, primary, secondary string, ttl, negativeTTL uint32, searchDomains []string) {
 	if enable {
-		p.Spec.LocalDNSProxy = new(LocalDNSProxy)
+		p.Spec.LocalDNSProxy = &LocalDNSProxy{
+			TTL:           ttl,
+			NegativeTTL:   negativeTTL,
+			SearchDomains: searchDomains,
+		}
 		if len(primary) > 0 || len(secondary) > 0 {
 			p.Spec.LocalDNSProxy.UpstreamDNSServers = new(UpstreamDNSServers)
 			if len(primary) > 0 {
@@ -39,6 +93,74 @@ func (p *PipyConf) setLocalDNSProxy(enable bool, primary, secondary string) {
 	}
 }
 
+func (p *PipyConf) setDNSResolveDB(servicesResolvableSet map[string][]policy.DNSRecord) {
+	if len(servicesResolvableSet) == 0 {
+		p.DNSResolveDB = nil
+		return
+	}
+	p.DNSResolveDB = make(map[string][]*DNSRecord)
+	for name, records := range servicesResolvableSet {
+		for _, record := range records {
+			p.DNSResolveDB[name] = append(p.DNSResolveDB[name], &DNSRecord{
+				Type:    string(record.Type),
+				Address: record.Address,
+				Target:  record.Target,
+				Port:    record.Port,
+			})
+		}
+	}
+}
+
+func (p *PipyConf) setTracing(enable bool, address, endpoint string, sampledFraction float32, propagation string) {
+	if enable {
+		p.Spec.Tracing = &TracingSpec{
+			Address:         address,
+			Endpoint:        endpoint,
+			SampledFraction: sampledFraction,
+			Propagation:     propagation,
+		}
+	} else {
+		p.Spec.Tracing = nil
+	}
+}
+
+func (p *PipyConf) setRemoteLogging(enable bool, address, endpoint, authorizationKey string, sampledFraction float32, fields []string) {
+	if enable {
+		p.Spec.RemoteLogging = &RemoteLoggingSpec{
+			Address:          address,
+			Endpoint:         endpoint,
+			AuthorizationKey: authorizationKey,
+			SampledFraction:  sampledFraction,
+			Fields:           fields,
+		}
+	} else {
+		p.Spec.RemoteLogging = nil
+	}
+}
+
+func (p *PipyConf) setClusterSet(vipCIDR string) {
+	if len(vipCIDR) > 0 {
+		p.Spec.ClusterSet = &ClusterSetSpec{
+			VIPCIDR: vipCIDR,
+		}
+	} else {
+		p.Spec.ClusterSet = nil
+	}
+}
+
+func (p *PipyConf) setClusterDomain(clusterDomain string) {
+	p.Spec.ClusterDomain = clusterDomain
+}
+
+func (p *PipyConf) setBridge(proxyPort, udpProxyPort, dnsProxyPort, dnsCapturePort uint16) {
+	p.Spec.Bridge = &BridgeSpec{
+		ProxyPort:      proxyPort,
+		UDPProxyPort:   udpProxyPort,
+		DNSProxyPort:   dnsProxyPort,
+		DNSCapturePort: dnsCapturePort,
+	}
+}
+
 func (p *PipyConf) newOutboundTrafficPolicy() *OutboundTrafficPolicy {
 	if p.Outbound == nil {
 		p.Outbound = new(OutboundTrafficPolicy)
@@ -100,6 +222,13 @@ func (otm *OutboundTrafficMatch) setProtocol(protocol Protocol) {
 	}
 }
 
+func (otm *OutboundTrafficMatch) addDestinationIPRange(ipRange DestinationIPRange) {
+	if otm.DestinationIPRanges == nil {
+		otm.DestinationIPRanges = make(DestinationIPRanges)
+	}
+	otm.DestinationIPRanges[ipRange] = nil
+}
+
 func (otm *OutboundTrafficMatch) newTCPServiceRouteRules() *OutboundTCPServiceRouteRules {
 	if otm.TCPServiceRouteRules == nil {
 		otm.TCPServiceRouteRules = new(OutboundTCPServiceRouteRules)
@@ -137,6 +266,25 @@ func (otm *OutboundTrafficMatch) newHTTPServiceRouteRules(httpRouteRuleName HTTP
 	return rules
 }
 
+func (otm *OutboundTrafficMatch) addTLSServerName2Service(serverName TLSServerName, serviceName ServiceName) {
+	if otm.TLSServerName2Service == nil {
+		otm.TLSServerName2Service = make(TLSServerName2Service)
+	}
+	otm.TLSServerName2Service[serverName] = serviceName
+}
+
+func (otm *OutboundTrafficMatch) newTLSServiceRouteRules(serviceName ServiceName) *OutboundTCPServiceRouteRules {
+	if otm.TLSServiceRouteRules == nil {
+		otm.TLSServiceRouteRules = make(OutboundTLSServiceRouteRules)
+	}
+	rules, exist := otm.TLSServiceRouteRules[serviceName]
+	if !exist || rules == nil {
+		rules = new(OutboundTCPServiceRouteRules)
+		otm.TLSServiceRouteRules[serviceName] = rules
+	}
+	return rules
+}
+
 func (otp *OutboundTrafficPolicy) newTrafficMatch(port Port, name string) (*OutboundTrafficMatch, bool) {
 	namedPort := fmt.Sprintf(`%d=%s`, port, name)
 	if otp.namedTrafficMatches == nil {
@@ -172,6 +320,80 @@ func (hrrs *OutboundHTTPRouteRules) newHTTPServiceRouteRule(matchRule *HTTPMatch
 	return routeRule, false
 }
 
+func (hrrs *OutboundHTTPRouteRules) setRateLimit(rateLimit *multiclusterv1alpha1.RateLimitSpec) {
+	if rateLimit == nil {
+		hrrs.RateLimit = nil
+		return
+	}
+	hrrs.RateLimit = newHTTPRateLimit(rateLimit.RequestsPerSecond, rateLimit.Burst,
+		rateLimit.ResponseStatusCode, rateLimit.ResponseHeadersToAdd)
+}
+
+func (hrr *OutboundHTTPRouteRule) setRateLimit(rateLimit *multiclusterv1alpha1.RouteRateLimitSpec) {
+	if rateLimit == nil {
+		hrr.RateLimit = nil
+		return
+	}
+	hrr.RateLimit = newHTTPRateLimit(rateLimit.RequestsPerSecond, rateLimit.Burst,
+		rateLimit.ResponseStatusCode, rateLimit.ResponseHeadersToAdd)
+}
+
+func newHTTPRateLimit(requestsPerSecond, burst, responseStatusCode uint32, responseHeadersToAdd []multiclusterv1alpha1.HTTPHeaderValue) *HTTPRateLimit {
+	localRateLimit := &HTTPLocalRateLimit{
+		Requests: requestsPerSecond,
+		// The bucket holds the baseline rate plus the burst
+		Burst:              requestsPerSecond + burst,
+		StatTimeWindow:     1,
+		ResponseStatusCode: responseStatusCode,
+	}
+	if localRateLimit.ResponseStatusCode == 0 {
+		localRateLimit.ResponseStatusCode = constants.DefaultRateLimitResponseStatusCode
+	}
+	for _, header := range responseHeadersToAdd {
+		localRateLimit.ResponseHeadersToAdd = append(localRateLimit.ResponseHeadersToAdd, HTTPHeaderValue{
+			Name:  header.Name,
+			Value: header.Value,
+		})
+	}
+	return &HTTPRateLimit{Local: localRateLimit}
+}
+
+func (hrr *OutboundHTTPRouteRule) setGRPCRoute(grpcRoute *multiclusterv1alpha1.GRPCRouteSpec) {
+	if grpcRoute == nil {
+		hrr.GRPC = nil
+		return
+	}
+	grpcRouteRule := &GRPCRouteRule{
+		Service: grpcRoute.Service,
+		Method:  grpcRoute.Method,
+	}
+	if grpcRoute.Timeout != nil {
+		grpcRouteRule.Timeout = grpcRoute.Timeout.Seconds()
+	}
+	if retryPolicy := grpcRoute.RetryPolicy; retryPolicy != nil && retryPolicy.NumRetries > 0 {
+		grpcRetryPolicy := &GRPCRetryPolicy{
+			NumRetries:               retryPolicy.NumRetries,
+			RetryBackoffBaseInterval: constants.DefaultGRPCRetryBackoffBaseInterval,
+		}
+		if retryPolicy.RetryBackoffBaseInterval != nil {
+			grpcRetryPolicy.RetryBackoffBaseInterval = retryPolicy.RetryBackoffBaseInterval.Seconds()
+		}
+		for _, retryOn := range retryPolicy.RetryOn {
+			code, ok := grpcStatusCodes[strings.ToLower(retryOn)]
+			if !ok {
+				log.Warn().Msgf("Ignoring unknown grpc status code %s in the retry policy of grpc route /%s/%s",
+					retryOn, grpcRoute.Service, grpcRoute.Method)
+				continue
+			}
+			grpcRetryPolicy.RetryOn = append(grpcRetryPolicy.RetryOn, code)
+		}
+		if len(grpcRetryPolicy.RetryOn) > 0 {
+			grpcRouteRule.RetryPolicy = grpcRetryPolicy
+		}
+	}
+	hrr.GRPC = grpcRouteRule
+}
+
 func (hmr *HTTPMatchRule) addHeaderMatch(header Header, headerRegexp HeaderRegexp) {
 	if hmr.Headers == nil {
 		hmr.Headers = make(Headers)
@@ -260,6 +482,16 @@ func (hrrs *OutboundHTTPRouteRuleSlice) Less(i, j int) bool {
 	if a.Path == constants.RegexMatchAll {
 		return false
 	}
+	if b.Path == constants.RegexMatchAll {
+		return true
+	}
+	// Exact matches take precedence over prefix matches, the longest prefix first, and prefix matches over regex matches
+	if a.Type != b.Type {
+		return uriMatchTypePrecedence[a.Type] < uriMatchTypePrecedence[b.Type]
+	}
+	if a.Type == PathMatchPrefix && len(a.Path) != len(b.Path) {
+		return len(a.Path) > len(b.Path)
+	}
 	return strings.Compare(string(a.Path), string(b.Path)) == -1
 }
 
@@ -7,13 +7,13 @@ import (
 
 	mapset "github.com/deckarep/golang-set"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/catalog"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/configurator"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/messaging"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/client"
-	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/codebase"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/proxyserver/registry"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/workerpool"
 )
@@ -32,8 +32,13 @@ var (
 	ecnetCodebaseRepo  = fmt.Sprintf("/%s", ecnetCodebase)
 )
 
+// getProxyCodebase returns the codebase derived for the bridge proxy
+func getProxyCodebase() string {
+	return fmt.Sprintf("%s/proxy.bridge.ecnet", ecnetProxyCodebase)
+}
+
 // NewRepoServer creates a new Aggregated Discovery Service server
-func NewRepoServer(meshCatalog catalog.MeshCataloger, proxyRegistry *registry.ProxyRegistry, ecnetNamespace string, cfg configurator.Configurator, kubecontroller k8s.Controller, msgBroker *messaging.Broker) *Server {
+func NewRepoServer(meshCatalog catalog.MeshCataloger, proxyRegistry *registry.ProxyRegistry, ecnetNamespace string, cfg configurator.Configurator, kubecontroller k8s.Controller, kubeClient kubernetes.Interface, msgBroker *messaging.Broker) *Server {
 	if len(cfg.GetRepoServerCodebase()) > 0 {
 		ecnetCodebase = fmt.Sprintf("%s/%s", cfg.GetRepoServerCodebase(), ecnetCodebase)
 		ecnetProxyCodebase = fmt.Sprintf("%s/%s", cfg.GetRepoServerCodebase(), ecnetProxyCodebase)
@@ -47,9 +52,11 @@ func NewRepoServer(meshCatalog catalog.MeshCataloger, proxyRegistry *registry.Pr
 		cfg:            cfg,
 		workQueues:     workerpool.NewWorkerPool(workerPoolSize),
 		kubeController: kubecontroller,
+		kubeClient:     kubeClient,
 		configVerMutex: sync.Mutex{},
 		configVersion:  make(map[string]uint64),
 		pluginSet:      mapset.NewSet(),
+		overlayFiles:   mapset.NewSet(),
 		msgBroker:      msgBroker,
 		repoClient:     client.NewRepoClient(cfg.GetRepoServerIPAddr(), uint16(cfg.GetProxyServerPort())),
 	}
@@ -70,12 +77,7 @@ func (s *Server) Start(_ uint32) error {
 		return success, nil
 	})
 
-	_, err := s.repoClient.Batch(fmt.Sprintf("%d", 0), []client.Batch{
-		{
-			Basepath: ecnetCodebase,
-			Items:    codebase.EcnetCodebaseItems,
-		},
-	})
+	_, err := s.uploadBaseCodebase(true)
 	if err != nil {
 		log.Error().Err(err)
 		return err
@@ -96,6 +98,12 @@ func (s *Server) Start(_ uint32) error {
 		return err
 	}
 
+	// Upgrade the proxy codebase derived from the previous base codebase,
+	// configs are not published until the bridges load it
+	if err = s.upgradeCodebase(s.getBridgeManifests()); err != nil {
+		log.Error().Err(err).Msg("Error upgrading proxy codebase, retrying later")
+	}
+
 	// Start broadcast listener thread
 	go s.broadcastListener()
 
@@ -2,9 +2,11 @@ package server
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 
 	mapset "github.com/deckarep/golang-set"
 
@@ -33,6 +35,7 @@ type Server struct {
 	ready          bool
 	workQueues     *workerpool.WorkerPool
 	kubeController k8s.Controller
+	kubeClient     kubernetes.Interface
 
 	// When snapshot cache is enabled, we (currently) don't keep track of proxy information, however different
 	// config versions have to be provided to the cache as we keep adding snapshots. The following map
@@ -42,6 +45,15 @@ type Server struct {
 
 	pluginSet mapset.Set
 
+	// overlayVersion is the version of the codebase overlays uploaded to the base codebase
+	overlayVersion uint64
+	// overlayFiles holds the files added or replaced by the codebase overlays
+	overlayFiles mapset.Set
+
+	// codebaseUpgraded tells whether the proxy codebase has been upgraded since the controller started.
+	// It is read and written from several goroutines.
+	codebaseUpgraded atomic.Bool
+
 	msgBroker *messaging.Broker
 
 	repoClient *client.PipyRepoClient
@@ -156,6 +168,61 @@ type UpstreamDNSServers struct {
 type LocalDNSProxy struct {
 	// UpstreamDNSServers defines upstream DNS servers for local DNS Proxy.
 	UpstreamDNSServers *UpstreamDNSServers `json:"UpstreamDNSServers,omitempty"`
+	// TTL defines the time to live in seconds of the records answered by local DNS Proxy.
+	TTL uint32 `json:"TTL,omitempty"`
+	// NegativeTTL defines the time in seconds local DNS Proxy caches the names that do not exist.
+	NegativeTTL uint32 `json:"NegativeTTL,omitempty"`
+	// SearchDomains defines the domains appended to the names not found as they are.
+	SearchDomains []string `json:"SearchDomains,omitempty"`
+}
+
+// DNSRecord is a resource record answered by local DNS Proxy
+type DNSRecord struct {
+	Type    string `json:"Type"`
+	Address string `json:"Address,omitempty"`
+	Target  string `json:"Target,omitempty"`
+	Port    uint16 `json:"Port,omitempty"`
+}
+
+// TracingSpec is the type to represent the tracing configuration.
+type TracingSpec struct {
+	// Address defines the tracing collector's address, ex. host:port
+	Address string `json:"Address"`
+	// Endpoint defines the API endpoint for tracing requests sent to the collector.
+	Endpoint string `json:"Endpoint"`
+	// SampledFraction defines the sampled fraction.
+	SampledFraction float32 `json:"SampledFraction"`
+	// Propagation defines the trace context formats propagated across clusters.
+	Propagation string `json:"Propagation"`
+}
+
+// RemoteLoggingSpec is the type to represent the remote logging configuration.
+type RemoteLoggingSpec struct {
+	// Address defines the remote logging's address, ex. host:port
+	Address string `json:"Address"`
+	// Endpoint defines the API endpoint for remote logging requests sent to the collector.
+	Endpoint string `json:"Endpoint"`
+	// AuthorizationKey defines the key of the access entity that allows to authorize someone in remote logging service,
+	// in the Secret given to the bridges. The access entity itself is never published.
+	AuthorizationKey string `json:"AuthorizationKey,omitempty"`
+	// SampledFraction defines the sampled fraction.
+	SampledFraction float32 `json:"SampledFraction"`
+	// Fields defines the fields of the access log to be shipped.
+	Fields []string `json:"Fields,omitempty"`
+}
+
+// ClusterSetSpec is the type to represent the cluster set configuration.
+type ClusterSetSpec struct {
+	// VIPCIDR defines the CIDR the virtual IPs of the ClusterSetIP imports are allocated from.
+	VIPCIDR string `json:"VIPCIDR"`
+}
+
+// BridgeSpec is the type to represent the ports of the bridge datapath, shared with the ebpf programs.
+type BridgeSpec struct {
+	ProxyPort      uint16 `json:"ProxyPort"`
+	UDPProxyPort   uint16 `json:"UDPProxyPort"`
+	DNSProxyPort   uint16 `json:"DNSProxyPort"`
+	DNSCapturePort uint16 `json:"DNSCapturePort"`
 }
 
 // EcnetConfigSpec represents the spec of mesh config
@@ -166,7 +233,12 @@ type EcnetConfigSpec struct {
 		LivenessProbes  []v1.Probe `json:"LivenessProbes,omitempty"`
 		StartupProbes   []v1.Probe `json:"StartupProbes,omitempty"`
 	}
-	LocalDNSProxy *LocalDNSProxy `json:"LocalDNSProxy,omitempty"`
+	LocalDNSProxy *LocalDNSProxy     `json:"LocalDNSProxy,omitempty"`
+	Tracing       *TracingSpec       `json:"Tracing,omitempty"`
+	RemoteLogging *RemoteLoggingSpec `json:"RemoteLogging,omitempty"`
+	ClusterSet    *ClusterSetSpec    `json:"ClusterSet,omitempty"`
+	ClusterDomain string             `json:"ClusterDomain,omitempty"`
+	Bridge        *BridgeSpec        `json:"Bridge,omitempty"`
 }
 
 // WeightedCluster is a struct of a cluster and is weight that is backing a service
@@ -174,9 +246,46 @@ type WeightedCluster struct {
 	service.WeightedCluster
 }
 
+// HTTPHeaderValue defines an HTTP header name/value pair
+type HTTPHeaderValue struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+// HTTPLocalRateLimit defines the local rate limiting of http requests
+type HTTPLocalRateLimit struct {
+	Requests             uint32            `json:"Requests"`
+	Burst                uint32            `json:"Burst"`
+	StatTimeWindow       uint32            `json:"StatTimeWindow"`
+	ResponseStatusCode   uint32            `json:"ResponseStatusCode"`
+	ResponseHeadersToAdd []HTTPHeaderValue `json:"ResponseHeadersToAdd,omitempty"`
+}
+
+// HTTPRateLimit defines the rate limiting of http requests
+type HTTPRateLimit struct {
+	Local *HTTPLocalRateLimit `json:"Local"`
+}
+
+// GRPCRetryPolicy defines the retry policy of grpc requests, keyed on the grpc status codes
+type GRPCRetryPolicy struct {
+	RetryOn                  []uint32 `json:"RetryOn"`
+	NumRetries               uint32   `json:"NumRetries"`
+	RetryBackoffBaseInterval float64  `json:"RetryBackoffBaseInterval"`
+}
+
+// GRPCRouteRule defines the grpc routing of the requests matching a route
+type GRPCRouteRule struct {
+	Service     string           `json:"Service"`
+	Method      string           `json:"Method,omitempty"`
+	Timeout     float64          `json:"Timeout,omitempty"`
+	RetryPolicy *GRPCRetryPolicy `json:"RetryPolicy,omitempty"`
+}
+
 // OutboundHTTPRouteRule http route rule
 type OutboundHTTPRouteRule struct {
 	HTTPRouteRule
+	RateLimit *HTTPRateLimit `json:"RateLimit,omitempty"`
+	GRPC      *GRPCRouteRule `json:"GRPC,omitempty"`
 }
 
 // OutboundHTTPRouteRuleSlice http route rule array
@@ -185,6 +294,7 @@ type OutboundHTTPRouteRuleSlice []*OutboundHTTPRouteRule
 // OutboundHTTPRouteRules is a wrapper type
 type OutboundHTTPRouteRules struct {
 	RouteRules OutboundHTTPRouteRuleSlice `json:"RouteRules"`
+	RateLimit  *HTTPRateLimit             `json:"RateLimit,omitempty"`
 }
 
 // OutboundHTTPServiceRouteRules is a wrapper type of map[HTTPRouteRuleName]*HTTPRouteRules
@@ -195,13 +305,35 @@ type OutboundTCPServiceRouteRules struct {
 	TargetClusters WeightedClusters `json:"TargetClusters"`
 }
 
+// TLSServerName is a string wrapper type
+type TLSServerName string
+
+// TLSServerName2Service is a wrapper type of map[TLSServerName]ServiceName
+type TLSServerName2Service map[TLSServerName]ServiceName
+
+// OutboundTLSServiceRouteRules is a wrapper type of map[ServiceName]*OutboundTCPServiceRouteRules
+type OutboundTLSServiceRouteRules map[ServiceName]*OutboundTCPServiceRouteRules
+
+// DestinationIPRange is a string wrapper type
+type DestinationIPRange string
+
+// DestinationSecuritySpec is the security spec of a destination ip range,
+// the bridge does not originate TLS to the imports so it is empty
+type DestinationSecuritySpec struct{}
+
+// DestinationIPRanges is a wrapper type of map[DestinationIPRange]*DestinationSecuritySpec
+type DestinationIPRanges map[DestinationIPRange]*DestinationSecuritySpec
+
 // OutboundTrafficMatch represents the match of OutboundTraffic
 type OutboundTrafficMatch struct {
+	DestinationIPRanges   DestinationIPRanges           `json:"DestinationIPRanges,omitempty"`
 	Port                  Port                          `json:"Port"`
 	Protocol              Protocol                      `json:"Protocol"`
 	HTTPHostPort2Service  HTTPHostPort2Service          `json:"HttpHostPort2Service"`
 	HTTPServiceRouteRules OutboundHTTPServiceRouteRules `json:"HttpServiceRouteRules"`
 	TCPServiceRouteRules  *OutboundTCPServiceRouteRules `json:"TcpServiceRouteRules"`
+	TLSServerName2Service TLSServerName2Service         `json:"TlsServerName2Service,omitempty"`
+	TLSServiceRouteRules  OutboundTLSServiceRouteRules  `json:"TlsServiceRouteRules,omitempty"`
 }
 
 // OutboundTrafficMatchSlice is a wrapper type of []*OutboundTrafficMatch
@@ -241,7 +373,7 @@ type PipyConf struct {
 	Ts           *time.Time
 	Version      *string
 	Spec         EcnetConfigSpec
-	Outbound     *OutboundTrafficPolicy   `json:"Outbound"`
-	Chains       map[string][]string      `json:"Chains,omitempty"`
-	DNSResolveDB map[string][]interface{} `json:"DNSResolveDB,omitempty"`
+	Outbound     *OutboundTrafficPolicy  `json:"Outbound"`
+	Chains       map[string][]string     `json:"Chains,omitempty"`
+	DNSResolveDB map[string][]*DNSRecord `json:"DNSResolveDB,omitempty"`
 }
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/errcode"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/client"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/pipy/repo/codebase"
+)
+
+const (
+	// bridgeManifestTimeout is the timeout of the requests for the manifest of the codebase loaded by a bridge
+	bridgeManifestTimeout = 3 * time.Second
+)
+
+// getManifestItem returns the manifest of the static resources of proxy codebase as a batch item.
+func getManifestItem() client.BatchItem {
+	bytes, _ := json.Marshal(codebase.GetManifest())
+	return client.BatchItem{
+		Filename: codebase.EcnetCodebaseManifest,
+		Content:  bytes,
+	}
+}
+
+// getBridgeManifest returns the manifest of the codebase loaded by the bridge proxy at the given address,
+// nil if the loaded codebase has no manifest.
+func getBridgeManifest(httpc *http.Client, address string) (*codebase.Manifest, error) {
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(address, strconv.Itoa(constants.ProxyStatsPort)), constants.ProxyManifestPath)
+	resp, err := httpc.Get(url) // #nosec G107: Potential HTTP request made with variable url
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint: errcheck,gosec
+
+	// The codebases predating the manifests don't serve it
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d getting %s", resp.StatusCode, url)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(codebase.Manifest)
+	if err = json.Unmarshal(content, manifest); err != nil {
+		// A malformed manifest is treated as an outdated one
+		return nil, nil
+	}
+	return manifest, nil
+}
+
+// getBridgeManifests returns the manifests of the codebases loaded by the running bridges keyed on their pod names,
+// the bridges that cannot be reached yet are left out.
+func (s *Server) getBridgeManifests() map[string]*codebase.Manifest {
+	pods, err := s.kubeClient.CoreV1().Pods(s.ecnetNamespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: labels.Set{constants.AppLabel: constants.ECNETBridgeName}.String(),
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("Error listing %s pods", constants.ECNETBridgeName)
+		return nil
+	}
+
+	httpc := &http.Client{Timeout: bridgeManifestTimeout}
+	manifests := make(map[string]*codebase.Manifest)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || len(pod.Status.PodIP) == 0 {
+			continue
+		}
+		manifest, err := getBridgeManifest(httpc, pod.Status.PodIP)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Error getting the codebase manifest of bridge %s", pod.Name)
+			continue
+		}
+		manifests[pod.Name] = manifest
+	}
+	return manifests
+}
+
+// upgradeCodebase upgrades in place the proxy codebase derived from an outdated base codebase,
+// the base codebase must have been uploaded before. The derived codebase is committed once,
+// when a bridge reports a manifest that differs from the one of the base codebase.
+func (s *Server) upgradeCodebase(bridgeManifests map[string]*codebase.Manifest) error {
+	if s.codebaseUpgraded.Load() {
+		return nil
+	}
+
+	manifest := codebase.GetManifest()
+	proxyCodebase := getProxyCodebase()
+
+	success, derived, err := s.repoClient.GetCodebase(proxyCodebase)
+	if err != nil || !success {
+		return fmt.Errorf("error getting codebase %s: %v", proxyCodebase, err)
+	}
+
+	// The proxy codebase is derived from the upgraded base codebase by the first config generation
+	if derived == nil {
+		s.codebaseUpgraded.Store(true)
+		return nil
+	}
+
+	var outdated string
+	for bridge, current := range bridgeManifests {
+		if current == nil || *current != manifest {
+			outdated = bridge
+			break
+		}
+	}
+	if len(outdated) == 0 {
+		return nil
+	}
+
+	var currentVersion string
+	if current := bridgeManifests[outdated]; current != nil {
+		currentVersion = current.Version
+	}
+	log.Info().Msgf("Upgrading codebase %s from version %q loaded by bridge %s to %q", proxyCodebase, currentVersion, outdated, manifest.Version)
+
+	// Committing the derived codebase makes the proxy reload the scripts of upgraded base codebase
+	if _, err = s.repoClient.Batch(derived.Version, []client.Batch{
+		{
+			Basepath: proxyCodebase,
+			Items:    []client.BatchItem{getManifestItem()},
+		},
+	}); err != nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrUploadingCodebase)).
+			Msgf("Error upgrading codebase %s", proxyCodebase)
+		return err
+	}
+	s.codebaseUpgraded.Store(true)
+	return nil
+}
+
+// isCodebaseCompatible returns whether the scripts of the proxy codebase loaded by the bridges
+// understand the config generated by this controller.
+func (s *Server) isCodebaseCompatible() bool {
+	bridgeManifests := s.getBridgeManifests()
+	for bridge, manifest := range bridgeManifests {
+		if manifest != nil && manifest.ConfigSchema == codebase.ConfigSchemaVersion {
+			continue
+		}
+		if manifest == nil {
+			log.Warn().Msgf("Bridge %s runs a proxy codebase without manifest, expected config schema version %d",
+				bridge, codebase.ConfigSchemaVersion)
+		} else {
+			log.Warn().Msgf("Bridge %s runs proxy codebase %s understanding config schema version %d, expected %d",
+				bridge, manifest.Version, manifest.ConfigSchema, codebase.ConfigSchemaVersion)
+		}
+		// Checked against the bridges again next time, until they load the upgraded codebase
+		if err := s.upgradeCodebase(bridgeManifests); err != nil {
+			log.Error().Err(err).Msg("Proxy codebase is not upgraded yet")
+		}
+		return false
+	}
+	return true
+}
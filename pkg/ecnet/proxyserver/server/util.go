@@ -28,6 +28,9 @@ func generatePipyOutboundTrafficRoutePolicy(pipyConf *PipyConf, outboundPolicy *
 		trafficMatchName := trafficMatch.Name
 		if destinationProtocol == constants.ProtocolHTTP || destinationProtocol == constants.ProtocolGRPC {
 			trafficMatchName = constants.ProtocolHTTP
+		} else if destinationProtocol == constants.ProtocolHTTPS {
+			// The https services sharing a port are told apart by SNI
+			trafficMatchName = constants.ProtocolHTTPS
 		}
 		tm, exist := otp.newTrafficMatch(Port(trafficMatch.DestinationPort), trafficMatchName)
 		if !exist {
@@ -49,6 +52,7 @@ func generatePipyOutboundTrafficRoutePolicy(pipyConf *PipyConf, outboundPolicy *
 			for _, httpRouteConfig := range httpRouteConfigs {
 				ruleName := HTTPRouteRuleName(httpRouteConfig.Name)
 				hsrrs := tm.newHTTPServiceRouteRules(ruleName)
+				hsrrs.setRateLimit(httpRouteConfig.RateLimit)
 				for _, hostname := range httpRouteConfig.Hostnames {
 					tm.addHTTPHostPort2Service(HTTPHostPort(hostname), ruleName)
 				}
@@ -75,6 +79,8 @@ func generatePipyOutboundTrafficRoutePolicy(pipyConf *PipyConf, outboundPolicy *
 					}
 
 					hsrr, _ := hsrrs.newHTTPServiceRouteRule(httpMatch)
+					hsrr.setRateLimit(route.RateLimit)
+					hsrr.setGRPCRoute(route.GRPCRoute)
 					for cluster := range route.WeightedClusters.Iter() {
 						serviceCluster := cluster.(service.WeightedCluster)
 						weightedCluster := new(WeightedCluster)
@@ -87,7 +93,11 @@ func generatePipyOutboundTrafficRoutePolicy(pipyConf *PipyConf, outboundPolicy *
 				}
 			}
 		} else if destinationProtocol == constants.ProtocolTCP ||
-			destinationProtocol == constants.ProtocolTCPServerFirst {
+			destinationProtocol == constants.ProtocolTCPServerFirst ||
+			destinationProtocol == constants.ProtocolUDP {
+			for _, ipRange := range trafficMatch.DestinationIPRanges {
+				tm.addDestinationIPRange(DestinationIPRange(ipRange))
+			}
 			tsrr := tm.newTCPServiceRouteRules()
 			for _, serviceCluster := range trafficMatch.WeightedClusters {
 				weightedCluster := new(WeightedCluster)
@@ -107,7 +117,11 @@ func generatePipyOutboundTrafficRoutePolicy(pipyConf *PipyConf, outboundPolicy *
 				continue
 			}
 
-			tsrr := tm.newTCPServiceRouteRules()
+			serviceName := ServiceName(upstreamSvcFQDN)
+			for _, serverName := range trafficMatch.ServerNames {
+				tm.addTLSServerName2Service(TLSServerName(serverName), serviceName)
+			}
+			tsrr := tm.newTLSServiceRouteRules(serviceName)
 			for _, httpRouteConfig := range httpRouteConfigs {
 				for _, route := range httpRouteConfig.Routes {
 					for cluster := range route.WeightedClusters.Iter() {
@@ -0,0 +1,145 @@
+package multicluster
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"net"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/announcements"
+	multiclusterv1alpha1 "github.com/flomesh-io/ErieCanal/pkg/ecnet/apis/multicluster/v1alpha1"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/configurator"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/errcode"
+	multiclusterClientset "github.com/flomesh-io/ErieCanal/pkg/ecnet/gen/client/multicluster/clientset/versioned"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s/informers"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/messaging"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
+)
+
+// GetClusterSetIPsForService retrieves the virtual IPs allocated to the imported service
+func (c *Client) GetClusterSetIPsForService(svc service.MeshService) []string {
+	importedServiceIf, exists, err := c.informers.GetByKey(informers.InformerKeyServiceImport, svc.NamespacedKey())
+	if !exists || err != nil {
+		return nil
+	}
+
+	importedService := importedServiceIf.(*multiclusterv1alpha1.ServiceImport)
+	if importedService.Spec.Type == multiclusterv1alpha1.Headless {
+		return nil
+	}
+	return importedService.Spec.IPs
+}
+
+// WatchAndAllocateClusterSetIPs allocates a virtual IP to every ClusterSetIP import from the CIDR
+// configured in EcnetConfig, and records it in the spec.ips of the import.
+// An allocated IP is kept as long as it belongs to the CIDR, so that it is stable across restarts.
+func (c *Client) WatchAndAllocateClusterSetIPs(mcClient multiclusterClientset.Interface, cfg configurator.Configurator, msgBroker *messaging.Broker, stop <-chan struct{}) {
+	kubePubSub := msgBroker.GetKubeEventPubSub()
+	updateChan := kubePubSub.Sub(
+		announcements.ServiceImportAdded.String(),
+		announcements.ServiceImportUpdated.String(),
+		announcements.EcnetConfigUpdated.String(),
+	)
+	defer msgBroker.Unsub(kubePubSub, updateChan)
+
+	c.allocateClusterSetIPs(mcClient, cfg.GetClusterSetVIPCIDR())
+
+	for {
+		select {
+		case <-stop:
+			log.Info().Msg("Received stop signal, exiting cluster set ip allocation routine")
+			return
+
+		case <-updateChan:
+			c.allocateClusterSetIPs(mcClient, cfg.GetClusterSetVIPCIDR())
+		}
+	}
+}
+
+func (c *Client) allocateClusterSetIPs(mcClient multiclusterClientset.Interface, vipCIDR string) {
+	if len(vipCIDR) == 0 {
+		return
+	}
+
+	_, vipNet, err := net.ParseCIDR(vipCIDR)
+	if err != nil || vipNet.IP.To4() == nil {
+		log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrInvalidClusterSetVIPCIDR)).
+			Msgf("Invalid cluster set vip cidr %s, expected an IPv4 CIDR", vipCIDR)
+		return
+	}
+
+	var importedServices []*multiclusterv1alpha1.ServiceImport
+	for _, importedServiceIf := range c.informers.List(informers.InformerKeyServiceImport) {
+		importedService := importedServiceIf.(*multiclusterv1alpha1.ServiceImport)
+		if importedService.Spec.Type == multiclusterv1alpha1.Headless {
+			continue
+		}
+		importedServices = append(importedServices, importedService)
+	}
+	// The first import claiming an IP keeps it
+	sort.Slice(importedServices, func(i, j int) bool {
+		return importedServices[i].CreationTimestamp.Before(&importedServices[j].CreationTimestamp)
+	})
+
+	allocatedIPs := make(map[string]bool)
+	var pendingServices []*multiclusterv1alpha1.ServiceImport
+	for _, importedService := range importedServices {
+		if len(importedService.Spec.IPs) > 0 {
+			ip := net.ParseIP(importedService.Spec.IPs[0])
+			if ip != nil && vipNet.Contains(ip) && !allocatedIPs[ip.String()] {
+				allocatedIPs[ip.String()] = true
+				continue
+			}
+		}
+		pendingServices = append(pendingServices, importedService)
+	}
+
+	for _, importedService := range pendingServices {
+		ip := nextFreeClusterSetIP(vipNet, allocatedIPs, importedService.Namespace+"/"+importedService.Name)
+		if ip == nil {
+			log.Error().Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrAllocatingClusterSetIP)).
+				Msgf("No virtual ip left in cluster set vip cidr %s for ServiceImport %s/%s", vipCIDR, importedService.Namespace, importedService.Name)
+			return
+		}
+
+		updatedService := importedService.DeepCopy()
+		updatedService.Spec.IPs = []string{ip.String()}
+		if _, err = mcClient.FlomeshV1alpha1().ServiceImports(updatedService.Namespace).Update(context.Background(), updatedService, metav1.UpdateOptions{}); err != nil {
+			// Retried on the next ServiceImport event
+			log.Error().Err(err).Str(errcode.Kind, errcode.GetErrCodeWithMetric(errcode.ErrUpdatingServiceImport)).
+				Msgf("Error recording virtual ip %s in ServiceImport %s/%s", ip, updatedService.Namespace, updatedService.Name)
+			continue
+		}
+		allocatedIPs[ip.String()] = true
+		log.Info().Msgf("Allocated virtual ip %s to ServiceImport %s/%s", ip, updatedService.Namespace, updatedService.Name)
+	}
+}
+
+// nextFreeClusterSetIP returns a free IP of vipNet, probing from an offset derived from the key
+// so that an import is likely to get the same IP back once released.
+// The network and broadcast addresses are never allocated.
+func nextFreeClusterSetIP(vipNet *net.IPNet, allocatedIPs map[string]bool, key string) net.IP {
+	ones, bits := vipNet.Mask.Size()
+	size := uint64(1) << uint(bits-ones)
+	base := binary.BigEndian.Uint32(vipNet.IP.To4())
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	start := uint64(hash.Sum32()) % size
+
+	for i := uint64(0); i < size; i++ {
+		offset := (start + i) % size
+		if size > 2 && (offset == 0 || offset == size-1) {
+			continue
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, base+uint32(offset))
+		if !allocatedIPs[ip.String()] {
+			return ip
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package multicluster
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	multiclusterv1alpha1 "github.com/flomesh-io/ErieCanal/pkg/ecnet/apis/multicluster/v1alpha1"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s/informers"
+)
+
+var (
+	importedServiceDesc = prometheus.NewDesc(
+		"ecnet_multicluster_imported_services",
+		"Represents the number of services imported from a cluster",
+		[]string{"cluster_key"}, nil)
+
+	importedEndpointDesc = prometheus.NewDesc(
+		"ecnet_multicluster_imported_endpoints",
+		"Represents the number of endpoints imported from a cluster",
+		[]string{"cluster_key"}, nil)
+)
+
+// importMetricsCollector computes the metrics of the imported services from the ServiceImport cache at scrape time
+type importMetricsCollector struct {
+	informers *informers.InformerCollection
+}
+
+// MetricsCollector returns the collector for the metrics of the services and endpoints imported per cluster key
+func (c *Client) MetricsCollector() prometheus.Collector {
+	return &importMetricsCollector{informers: c.informers}
+}
+
+// Describe implements prometheus.Collector
+func (mc *importMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- importedServiceDesc
+	ch <- importedEndpointDesc
+}
+
+// Collect implements prometheus.Collector
+func (mc *importMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	serviceCount := make(map[string]int)
+	endpointCount := make(map[string]int)
+
+	for _, importedServiceIf := range mc.informers.List(informers.InformerKeyServiceImport) {
+		importedService := importedServiceIf.(*multiclusterv1alpha1.ServiceImport)
+		clusterKeys := make(map[string]bool)
+		// An endpoint is listed once per port of the imported service
+		endpoints := make(map[multiclusterv1alpha1.Endpoint]bool)
+		for _, port := range importedService.Spec.Ports {
+			for _, endpoint := range port.Endpoints {
+				clusterKeys[endpoint.ClusterKey] = true
+				endpoint.Target.Port = 0
+				endpoint.Target.Path = ""
+				if !endpoints[endpoint] {
+					endpoints[endpoint] = true
+					endpointCount[endpoint.ClusterKey]++
+				}
+			}
+		}
+		for clusterKey := range clusterKeys {
+			serviceCount[clusterKey]++
+		}
+	}
+
+	for clusterKey, count := range serviceCount {
+		ch <- prometheus.MustNewConstMetric(importedServiceDesc, prometheus.GaugeValue, float64(count), clusterKey)
+	}
+	for clusterKey, count := range endpointCount {
+		ch <- prometheus.MustNewConstMetric(importedEndpointDesc, prometheus.GaugeValue, float64(count), clusterKey)
+	}
+}
@@ -29,8 +29,9 @@ func (c *Client) GetService(svc service.MeshService) *corev1.Service {
 		return nil
 	}
 
+	headless := importedService.Spec.Type == multiclusterv1alpha1.Headless
 	for _, port := range importedService.Spec.Ports {
-		if strings.EqualFold(importedService.Name, svc.Name) &&
+		if strings.EqualFold(importedService.Name, svc.ProviderKey()) &&
 			uint16(port.Port) == svc.Port &&
 			len(port.Endpoints) > 0 {
 			targetSvc := new(corev1.Service)
@@ -42,9 +43,18 @@ func (c *Client) GetService(svc service.MeshService) *corev1.Service {
 			targetSvc.Spec.Selector["app"] = importedService.Name
 			for _, endpoint := range port.Endpoints {
 				if svc.TargetPort == uint16(endpoint.Target.Port) {
-					if len(targetSvc.Spec.ClusterIP) == 0 {
-						targetSvc.Spec.ClusterIP = endpoint.Target.IP
-						targetSvc.Spec.ClusterIPs = append(targetSvc.Spec.ClusterIPs, targetSvc.Spec.ClusterIP)
+					if len(targetSvc.Spec.Ports) == 0 {
+						// Headless imports are resolved to their endpoints
+						if headless {
+							targetSvc.Spec.ClusterIP = corev1.ClusterIPNone
+						} else if len(importedService.Spec.IPs) > 0 {
+							// The virtual IP allocated to the import
+							targetSvc.Spec.ClusterIP = importedService.Spec.IPs[0]
+							targetSvc.Spec.ClusterIPs = append(targetSvc.Spec.ClusterIPs, targetSvc.Spec.ClusterIP)
+						} else {
+							targetSvc.Spec.ClusterIP = endpoint.Target.IP
+							targetSvc.Spec.ClusterIPs = append(targetSvc.Spec.ClusterIPs, targetSvc.Spec.ClusterIP)
+						}
 						targetSvcPort := corev1.ServicePort{
 							Name:        port.Name,
 							Protocol:    port.Protocol,
@@ -98,8 +108,12 @@ func (c *Client) ListServices() []*corev1.Service {
 					targetSvc.Spec.Type = corev1.ServiceTypeClusterIP
 					targetSvc.Spec.Selector = make(map[string]string)
 					targetSvc.Spec.Selector["app"] = importedService.Name
-					targetSvc.Spec.ClusterIP = endpoint.Target.IP
-					targetSvc.Spec.ClusterIPs = append(targetSvc.Spec.ClusterIPs, targetSvc.Spec.ClusterIP)
+					if importedService.Spec.Type == multiclusterv1alpha1.Headless {
+						targetSvc.Spec.ClusterIP = corev1.ClusterIPNone
+					} else {
+						targetSvc.Spec.ClusterIP = endpoint.Target.IP
+						targetSvc.Spec.ClusterIPs = append(targetSvc.Spec.ClusterIPs, targetSvc.Spec.ClusterIP)
+					}
 					targetSvcPort := corev1.ServicePort{
 						Name:        port.Name,
 						Protocol:    port.Protocol,
@@ -194,7 +208,7 @@ func (c *Client) GetEndpoints(svc service.MeshService) (*corev1.Endpoints, error
 	}
 
 	for _, port := range importedService.Spec.Ports {
-		if strings.EqualFold(importedService.Name, svc.Name) &&
+		if strings.EqualFold(importedService.Name, svc.ProviderKey()) &&
 			(svc.Port == 0 || svc.Port == uint16(port.Port)) &&
 			len(port.Endpoints) > 0 {
 			targetEndpoints := new(corev1.Endpoints)
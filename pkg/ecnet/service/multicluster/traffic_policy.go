@@ -82,3 +82,21 @@ func (c *Client) GetLbWeightForService(svc service.MeshService) (aa, fo, lc bool
 	lc = true
 	return
 }
+
+// GetRateLimitForService retrieves the rate limiting applied to the requests sent to the service
+func (c *Client) GetRateLimitForService(svc service.MeshService) *multiclusterv1alpha1.RateLimitSpec {
+	gblTrafficPolicy := c.getGlobalTrafficPolicy(svc)
+	if gblTrafficPolicy != nil {
+		return gblTrafficPolicy.Spec.RateLimit
+	}
+	return nil
+}
+
+// GetGRPCRoutesForService retrieves the routing of the gRPC requests sent to the service
+func (c *Client) GetGRPCRoutesForService(svc service.MeshService) []multiclusterv1alpha1.GRPCRouteSpec {
+	gblTrafficPolicy := c.getGlobalTrafficPolicy(svc)
+	if gblTrafficPolicy != nil {
+		return gblTrafficPolicy.Spec.GRPCRoutes
+	}
+	return nil
+}
@@ -6,11 +6,17 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 
+	multiclusterv1alpha1 "github.com/flomesh-io/ErieCanal/pkg/ecnet/apis/multicluster/v1alpha1"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/k8s/informers"
+	"github.com/flomesh-io/ErieCanal/pkg/ecnet/logger"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
 )
 
+var (
+	log = logger.New("multicluster")
+)
+
 const (
 	// ServiceImportClusterKeyAnnotation is the annotation used to configure context path for imported service
 	ServiceImportClusterKeyAnnotation = "flomesh.io/ServiceImport/ClusterKey/%s/%d"
@@ -54,4 +60,13 @@ type Controller interface {
 
 	// GetLbWeightForService retrieves load balancer type and weight for service
 	GetLbWeightForService(svc service.MeshService) (aa, fo, lc bool, weight int, clusterKeys map[string]int)
+
+	// GetRateLimitForService retrieves the rate limiting applied to the requests sent to the service
+	GetRateLimitForService(svc service.MeshService) *multiclusterv1alpha1.RateLimitSpec
+
+	// GetGRPCRoutesForService retrieves the routing of the gRPC requests sent to the service
+	GetGRPCRoutesForService(svc service.MeshService) []multiclusterv1alpha1.GRPCRouteSpec
+
+	// GetClusterSetIPsForService retrieves the virtual IPs allocated to the imported service
+	GetClusterSetIPsForService(svc service.MeshService) []string
 }
@@ -2,10 +2,12 @@ package policy
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 
 	mapset "github.com/deckarep/golang-set"
 
+	multiclusterv1alpha1 "github.com/flomesh-io/ErieCanal/pkg/ecnet/apis/multicluster/v1alpha1"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/constants"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
 )
@@ -61,3 +63,51 @@ func (out *OutboundTrafficPolicy) AddRoute(httpRouteMatch HTTPRouteMatch, weight
 
 	return nil
 }
+
+// AddRateLimitedRoute adds a route to an OutboundTrafficPolicy for the requests subject to the given route rate limiting.
+// The route matches the requests by path regex and methods, and is sent to the given weighted clusters.
+func (out *OutboundTrafficPolicy) AddRateLimitedRoute(routeRateLimit multiclusterv1alpha1.RouteRateLimitSpec, weightedClusters ...service.WeightedCluster) error {
+	httpRouteMatch := HTTPRouteMatch{
+		Path:          routeRateLimit.Path,
+		PathMatchType: PathMatchRegex,
+		Methods:       routeRateLimit.Methods,
+	}
+	if len(httpRouteMatch.Methods) == 0 {
+		httpRouteMatch.Methods = []string{constants.WildcardHTTPMethod}
+	}
+
+	if err := out.AddRoute(httpRouteMatch, weightedClusters...); err != nil {
+		return err
+	}
+
+	for _, existingRoute := range out.Routes {
+		if reflect.DeepEqual(existingRoute.HTTPRouteMatch, httpRouteMatch) {
+			existingRoute.RateLimit = &routeRateLimit
+		}
+	}
+	return nil
+}
+
+// AddGRPCRoute adds a route to an OutboundTrafficPolicy for the gRPC requests matching the given gRPC route.
+// The route matches the requests by the path of the gRPC service and method, and is sent to the given weighted clusters.
+func (out *OutboundTrafficPolicy) AddGRPCRoute(grpcRoute multiclusterv1alpha1.GRPCRouteSpec, weightedClusters ...service.WeightedCluster) error {
+	httpRouteMatch := HTTPRouteMatch{
+		Path:          fmt.Sprintf("/%s/%s", grpcRoute.Service, grpcRoute.Method),
+		PathMatchType: PathMatchExact,
+		Methods:       []string{http.MethodPost},
+	}
+	if len(grpcRoute.Method) == 0 {
+		httpRouteMatch.PathMatchType = PathMatchPrefix
+	}
+
+	if err := out.AddRoute(httpRouteMatch, weightedClusters...); err != nil {
+		return err
+	}
+
+	for _, existingRoute := range out.Routes {
+		if reflect.DeepEqual(existingRoute.HTTPRouteMatch, httpRouteMatch) {
+			existingRoute.GRPCRoute = &grpcRoute
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ package policy
 import (
 	mapset "github.com/deckarep/golang-set"
 
+	multiclusterv1alpha1 "github.com/flomesh-io/ErieCanal/pkg/ecnet/apis/multicluster/v1alpha1"
 	"github.com/flomesh-io/ErieCanal/pkg/ecnet/service"
 )
 
@@ -52,6 +53,14 @@ type TCPRouteMatch struct {
 type RouteWeightedClusters struct {
 	HTTPRouteMatch   HTTPRouteMatch `json:"http_route_match:omitempty"`
 	WeightedClusters mapset.Set     `json:"weighted_clusters:omitempty"`
+
+	// RateLimit defines the rate limiting applied to the requests matching the route
+	// +optional
+	RateLimit *multiclusterv1alpha1.RouteRateLimitSpec `json:"rate_limit:omitempty"`
+
+	// GRPCRoute defines the gRPC routing applied to the requests matching the route
+	// +optional
+	GRPCRoute *multiclusterv1alpha1.GRPCRouteSpec `json:"grpc_route:omitempty"`
 }
 
 // Rule is a struct that represents which authenticated principals can access a Route.
@@ -67,6 +76,10 @@ type OutboundTrafficPolicy struct {
 	Name      string                   `json:"name:omitempty"`
 	Hostnames []string                 `json:"hostnames"`
 	Routes    []*RouteWeightedClusters `json:"routes:omitempty"`
+
+	// RateLimit defines the rate limiting applied to the requests sent to the upstream service
+	// +optional
+	RateLimit *multiclusterv1alpha1.RateLimitSpec `json:"rate_limit:omitempty"`
 }
 
 // OutboundMeshTrafficPolicy is the type used to represent the outbound mesh traffic policy configurations
@@ -87,8 +100,34 @@ type OutboundMeshTrafficPolicy struct {
 	// mesh destinations.
 	ClustersConfigs []*MeshClusterConfig
 
-	// ServicesResolvableSet defines the dns database
-	ServicesResolvableSet map[string][]interface{}
+	// ServicesResolvableSet defines the dns database, as the records keyed by name
+	ServicesResolvableSet map[string][]DNSRecord
+}
+
+// DNSRecordType is the type of a DNS resource record
+type DNSRecordType string
+
+const (
+	// DNSRecordTypeA is the type of an IPv4 address record
+	DNSRecordTypeA DNSRecordType = "A"
+
+	// DNSRecordTypeSRV is the type of a service locator record
+	DNSRecordTypeSRV DNSRecordType = "SRV"
+)
+
+// DNSRecord is a resource record answered by the local DNS proxy
+type DNSRecord struct {
+	// Type is the type of the record
+	Type DNSRecordType
+
+	// Address is the IPv4 address of an A record
+	Address string
+
+	// Target is the host name an SRV record points to
+	Target string
+
+	// Port is the port an SRV record points to
+	Port uint16
 }
 
 // MeshClusterConfig is the type used to represent a cluster configuration that is programmed
@@ -121,6 +160,10 @@ type TrafficMatch struct {
 	// DestinationProtocol defines the protocol served by DestinationPort
 	DestinationProtocol string
 
+	// DestinationIPRanges defines the list of destination IP ranges
+	// +optional
+	DestinationIPRanges []string
+
 	// ServerNames defines the list of server names to be used as SNI when the
 	// DestinationProtocol is TLS based, ex. when the DestinationProtocol is 'https'
 	// +optional
@@ -149,6 +192,9 @@ type Plugin struct {
 	// Name defines the Name of the plugin.
 	Name string
 
+	// Namespace defines the namespace of the custom plugin, empty for the built-in ones.
+	Namespace string
+
 	// priority defines the priority of the plugin.
 	Priority float32
 
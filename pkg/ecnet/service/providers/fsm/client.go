@@ -154,6 +154,11 @@ func ServiceToMeshServices(c multicluster.Controller, svc corev1.Service) []serv
 		// use port.appProtocol if specified, else use port protocol
 		meshSvc.Protocol = pointer.StringDeref(portSpec.AppProtocol, protocol)
 
+		// UDP ports can only be proxied as UDP, whatever the port name or appProtocol
+		if portSpec.Protocol == corev1.ProtocolUDP {
+			meshSvc.Protocol = constants.ProtocolUDP
+		}
+
 		// The endpoints for the kubernetes service carry information that allows
 		// us to retrieve the TargetPort for the MeshService.
 		endpoints, _ := c.GetEndpoints(meshSvc)
@@ -171,6 +176,25 @@ func ServiceToMeshServices(c multicluster.Controller, svc corev1.Service) []serv
 					Protocol:         meshSvc.Protocol,
 					ServiceImportUID: meshSvc.ServiceImportUID,
 				})
+
+				if svc.Spec.ClusterIP != corev1.ClusterIPNone {
+					continue
+				}
+
+				// Each endpoint of a headless import is addressable by its hostname
+				for _, address := range subset.Addresses {
+					if address.Hostname == "" {
+						continue
+					}
+					meshServices = append(meshServices, service.MeshService{
+						Namespace:        meshSvc.Namespace,
+						Name:             fmt.Sprintf("%s.%s", address.Hostname, meshSvc.Name),
+						Port:             meshSvc.Port,
+						TargetPort:       uint16(port.Port),
+						Protocol:         meshSvc.Protocol,
+						ServiceImportUID: meshSvc.ServiceImportUID,
+					})
+				}
 			}
 		}
 	}
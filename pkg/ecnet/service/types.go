@@ -8,6 +8,28 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
+const (
+	// ClusterSetDomain is the domain of the names resolving to the services imported from the cluster set,
+	// as defined by the Kubernetes multi-cluster services API
+	ClusterSetDomain = "clusterset.local"
+)
+
+var (
+	clusterDomain = "cluster.local"
+)
+
+// SetClusterDomain sets the DNS domain of the local cluster
+func SetClusterDomain(domain string) {
+	if len(domain) > 0 {
+		clusterDomain = strings.Trim(domain, ".")
+	}
+}
+
+// GetClusterDomain returns the DNS domain of the local cluster, ie: cluster.local
+func GetClusterDomain() string {
+	return clusterDomain
+}
+
 // MeshService is the struct representing a service (Kubernetes or otherwise) within the service mesh.
 type MeshService struct {
 	// If the service resides on a Kubernetes service, this would be the Kubernetes namespace.
@@ -86,7 +108,12 @@ func (ms MeshService) ClusterName() string {
 
 // FQDN is similar to String(), but uses a dot separator and is in a different order.
 func (ms MeshService) FQDN() string {
-	return fmt.Sprintf("%s.%s.svc.cluster.local", ms.Name, ms.Namespace)
+	return fmt.Sprintf("%s.%s.svc.%s", ms.Name, ms.Namespace, clusterDomain)
+}
+
+// ClusterSetFQDN is the name of the MeshService in the cluster set domain, ie: service.namespace.svc.clusterset.local
+func (ms MeshService) ClusterSetFQDN() string {
+	return fmt.Sprintf("%s.%s.svc.%s", ms.Name, ms.Namespace, ClusterSetDomain)
 }
 
 // OutboundTrafficMatchName returns the MeshService outbound traffic match name